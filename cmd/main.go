@@ -9,6 +9,11 @@ import (
 	"blog/middlewares"
 	"blog/modules/user"
 	"blog/modules/auth"
+	"blog/modules/rbac"
+	"blog/modules/oauth"
+	"blog/modules/apikey"
+	"blog/pkg/constants"
+	"blog/pkg/storage"
 
 	"github.com/samber/do"
 	"github.com/common-nighthawk/go-figure"
@@ -26,8 +31,6 @@ func args(injector *do.Injector) bool {
 }
 
 func run(server *gin.Engine) {
-	server.Static("/assets", "./assets")
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8888"
@@ -58,6 +61,16 @@ func main() {
 
 	user.RegisterRoutes(server, injector)
 	auth.RegisterRoutes(server, injector)
+	rbac.RegisterRoutes(server, injector)
+	oauth.RegisterRoutes(server, injector)
+	apikey.RegisterRoutes(server, injector)
+
+	// Only storages serving their own signed URLs need a route, S3 URLs go to S3
+	fileStorage := do.MustInvokeNamed[storage.Storage](injector, constants.Storage)
+	if fileServer, ok := fileStorage.(storage.Server); ok {
+		server.GET(fileServer.MountPath()+"/*key", gin.WrapH(fileServer))
+		server.HEAD(fileServer.MountPath()+"/*key", gin.WrapH(fileServer))
+	}
 
 	run(server)
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"time"
+)
+
+type AvatarConfig struct {
+	// MaxSize is the largest upload accepted, in bytes
+	MaxSize int
+	// MinDimension and MaxDimension bound the width and height in pixels
+	MinDimension int
+	MaxDimension int
+	// URLExpiry is how long the signed URLs handed out for avatars work
+	URLExpiry time.Duration
+}
+
+func NewAvatarConfig() (*AvatarConfig, error) {
+	maxSize, err := getIntEnv("AVATAR_MAX_SIZE", 5<<20)
+	if err != nil {
+		return nil, err
+	}
+
+	minDimension, err := getIntEnv("AVATAR_MIN_DIMENSION", 64)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDimension, err := getIntEnv("AVATAR_MAX_DIMENSION", 4096)
+	if err != nil {
+		return nil, err
+	}
+
+	urlExpiry, err := getDurationEnv("AVATAR_URL_EXPIRY", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AvatarConfig{
+		MaxSize:      maxSize,
+		MinDimension: minDimension,
+		MaxDimension: maxDimension,
+		URLExpiry:    urlExpiry,
+	}, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+type DataExportConfig struct {
+	// Expiry is how long the emailed download link works; the archive is
+	// deleted from the storage afterwards
+	Expiry time.Duration
+	// InlineMaxRecords is the most records an export may hold to be built and
+	// returned in the response; larger ones are built in the background and
+	// emailed as a link valid for Expiry
+	InlineMaxRecords int
+	// At most MaxPerUser exports are made for one user within RateWindow
+	MaxPerUser int
+	RateWindow time.Duration
+	// SweepInterval is how often expired archives are deleted, 0 disables it
+	SweepInterval time.Duration
+}
+
+func NewDataExportConfig() (*DataExportConfig, error) {
+	expiry, err := getDurationEnv("DATA_EXPORT_EXPIRY", time.Hour*24)
+	if err != nil {
+		return nil, err
+	}
+
+	// The link is presigned, so S3 would refuse every export only once it is built
+	if getEnv("STORAGE_DRIVER", StorageDriverLocal) == StorageDriverS3 && expiry > S3MaxSignedURLExpiry {
+		return nil, fmt.Errorf("DATA_EXPORT_EXPIRY %s is longer than the %s S3 allows for a signed URL", expiry, S3MaxSignedURLExpiry)
+	}
+
+	inlineMaxRecords, err := getIntEnv("DATA_EXPORT_INLINE_MAX_RECORDS", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPerUser, err := getIntEnv("DATA_EXPORT_MAX_PER_USER", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	rateWindow, err := getDurationEnv("DATA_EXPORT_RATE_WINDOW", time.Hour*24)
+	if err != nil {
+		return nil, err
+	}
+
+	sweepInterval, err := getDurationEnv("DATA_EXPORT_SWEEP_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataExportConfig{
+		Expiry:           expiry,
+		InlineMaxRecords: inlineMaxRecords,
+		MaxPerUser:       maxPerUser,
+		RateWindow:       rateWindow,
+		SweepInterval:    sweepInterval,
+	}, nil
+}
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/Caknoooo/go-gin-clean-starter/pkg/constants"
+	"blog/pkg/constants"
 	"github.com/joho/godotenv"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -51,4 +51,4 @@ func CloseDatabaseConnection(db *gorm.DB) {
 		panic(err)
 	}
 	dbSQL.Close()
-}
\ No newline at end of file
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"time"
+)
+
+type EmailChangeConfig struct {
+	// URL is the page the confirmation link opens, the token is added as ?token=
+	URL    string
+	Expiry time.Duration
+}
+
+func NewEmailChangeConfig() (*EmailChangeConfig, error) {
+	expiry, err := getDurationEnv("EMAIL_CHANGE_EXPIRY", time.Hour*24)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EmailChangeConfig{
+		URL:    getEnv("EMAIL_CHANGE_URL", "http://localhost:3000/account/confirm-email"),
+		Expiry: expiry,
+	}, nil
+}
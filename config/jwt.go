@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type JWTConfig struct {
+	// SigningAlg is HS256 (keyed with Secret), RS256 or EdDSA (keys from PrivateKeys)
+	SigningAlg       string
+	Secret           string
+	RefreshSecret    string
+	PrivateKeys      []string
+	RotationInterval time.Duration
+	GracePeriod      time.Duration
+	Issuer           string
+	Audience         string
+	Leeway           time.Duration
+	AccessExpiry     time.Duration
+	RefreshExpiry    time.Duration
+}
+
+func NewJWTConfig() (*JWTConfig, error) {
+	config := JWTConfig{
+		SigningAlg:    getEnv("JWT_SIGNING_ALG", "HS256"),
+		Secret:        os.Getenv("JWT_SECRET"),
+		RefreshSecret: getEnv("REFRESH_TOKEN_SECRET", os.Getenv("JWT_SECRET")),
+		Issuer:        getEnv("JWT_ISSUER", "Template"),
+		Audience:      os.Getenv("JWT_AUDIENCE"),
+	}
+
+	for _, path := range strings.Split(os.Getenv("JWT_PRIVATE_KEYS"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			config.PrivateKeys = append(config.PrivateKeys, path)
+		}
+	}
+
+	durations := []struct {
+		name     string
+		target   *time.Duration
+		fallback time.Duration
+	}{
+		{"JWT_ACCESS_EXPIRY", &config.AccessExpiry, time.Minute * 15},
+		{"JWT_REFRESH_EXPIRY", &config.RefreshExpiry, time.Hour * 24 * 7},
+		{"JWT_LEEWAY", &config.Leeway, time.Second * 30},
+		{"JWT_KEY_ROTATION_INTERVAL", &config.RotationInterval, 0},
+		{"JWT_KEY_GRACE_PERIOD", &config.GracePeriod, 0},
+	}
+
+	for _, d := range durations {
+		value, err := getDurationEnv(d.name, d.fallback)
+		if err != nil {
+			return nil, err
+		}
+		*d.target = value
+	}
+
+	// Old keys must outlive every token they signed
+	minGracePeriod := config.AccessExpiry + config.Leeway
+	if config.GracePeriod == 0 {
+		config.GracePeriod = minGracePeriod
+	}
+	if config.GracePeriod < minGracePeriod {
+		return nil, fmt.Errorf(
+			"JWT_KEY_GRACE_PERIOD %s is shorter than JWT_ACCESS_EXPIRY plus JWT_LEEWAY (%s)",
+			config.GracePeriod, minGracePeriod,
+		)
+	}
+
+	return &config, nil
+}
+
+func getEnv(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDurationEnv(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return duration, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+type LoginThrottleConfig struct {
+	// MaxAttempts failed logins on one account within Window lock it
+	MaxAttempts int
+	// MaxIPAttempts failed logins from one IP within Window lock the IP out
+	MaxIPAttempts   int
+	Window          time.Duration
+	LockoutDuration time.Duration
+	// Every failure doubles the wait before the next attempt, up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+func NewLoginThrottleConfig() (*LoginThrottleConfig, error) {
+	config := LoginThrottleConfig{}
+
+	ints := []struct {
+		name     string
+		target   *int
+		fallback int
+	}{
+		{"LOGIN_MAX_ATTEMPTS", &config.MaxAttempts, 5},
+		{"LOGIN_MAX_IP_ATTEMPTS", &config.MaxIPAttempts, 20},
+	}
+
+	for _, i := range ints {
+		value, err := getIntEnv(i.name, i.fallback)
+		if err != nil {
+			return nil, err
+		}
+		*i.target = value
+	}
+
+	durations := []struct {
+		name     string
+		target   *time.Duration
+		fallback time.Duration
+	}{
+		{"LOGIN_ATTEMPT_WINDOW", &config.Window, time.Minute * 15},
+		{"LOGIN_LOCKOUT_DURATION", &config.LockoutDuration, time.Minute * 15},
+		{"LOGIN_DELAY_BASE", &config.BaseDelay, time.Second},
+		{"LOGIN_DELAY_MAX", &config.MaxDelay, time.Second * 30},
+	}
+
+	for _, d := range durations {
+		value, err := getDurationEnv(d.name, d.fallback)
+		if err != nil {
+			return nil, err
+		}
+		*d.target = value
+	}
+
+	return &config, nil
+}
+
+func getIntEnv(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return number, nil
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"time"
+)
+
+type MagicLinkConfig struct {
+	// URL is the page the emailed link opens, the token is added as ?token=
+	URL    string
+	Expiry time.Duration
+	// At most MaxPerEmail links are sent to one address and MaxPerIP
+	// requested from one IP within RateWindow
+	MaxPerEmail int
+	MaxPerIP    int
+	RateWindow  time.Duration
+}
+
+func NewMagicLinkConfig() (*MagicLinkConfig, error) {
+	expiry, err := getDurationEnv("MAGIC_LINK_EXPIRY", time.Minute*15)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPerEmail, err := getIntEnv("MAGIC_LINK_MAX_PER_EMAIL", 3)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPerIP, err := getIntEnv("MAGIC_LINK_MAX_PER_IP", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	rateWindow, err := getDurationEnv("MAGIC_LINK_RATE_WINDOW", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MagicLinkConfig{
+		URL:         getEnv("MAGIC_LINK_URL", "http://localhost:3000/auth/magic-link"),
+		Expiry:      expiry,
+		MaxPerEmail: maxPerEmail,
+		MaxPerIP:    maxPerIP,
+		RateWindow:  rateWindow,
+	}, nil
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	OAuthKindOIDC   = "oidc"
+	OAuthKindGitHub = "github"
+)
+
+// OAuthProviderConfig describes a social login provider. OIDC providers only
+// need an issuer, their endpoints are discovered; setting the endpoints
+// explicitly skips discovery.
+type OAuthProviderConfig struct {
+	Name         string
+	Kind         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	Scopes       []string
+}
+
+// NewOAuthConfig reads the providers listed in OAUTH_PROVIDERS. Each provider
+// is configured through OAUTH_<NAME>_* variables; google and github come with
+// their public endpoints, any other name is a generic OIDC provider.
+func NewOAuthConfig() ([]OAuthProviderConfig, error) {
+	var providers []OAuthProviderConfig
+
+	for _, name := range strings.Split(os.Getenv("OAUTH_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		provider := defaultOAuthProvider(name)
+		provider.Name = name
+		provider.ClientID = os.Getenv(prefix + "CLIENT_ID")
+		provider.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		provider.RedirectURL = os.Getenv(prefix + "REDIRECT_URL")
+		provider.Issuer = getEnv(prefix+"ISSUER", provider.Issuer)
+		provider.AuthURL = getEnv(prefix+"AUTH_URL", provider.AuthURL)
+		provider.TokenURL = getEnv(prefix+"TOKEN_URL", provider.TokenURL)
+		provider.UserInfoURL = getEnv(prefix+"USERINFO_URL", provider.UserInfoURL)
+
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		if provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("%sCLIENT_ID and %sREDIRECT_URL must be set", prefix, prefix)
+		}
+
+		if provider.Kind == OAuthKindOIDC && provider.Issuer == "" && provider.AuthURL == "" {
+			return nil, fmt.Errorf("%sISSUER must be set", prefix)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+func defaultOAuthProvider(name string) OAuthProviderConfig {
+	switch name {
+	case "google":
+		return OAuthProviderConfig{
+			Kind:   OAuthKindOIDC,
+			Issuer: "https://accounts.google.com",
+			Scopes: []string{"openid", "email", "profile"},
+		}
+	case "github":
+		return OAuthProviderConfig{
+			Kind:        OAuthKindGitHub,
+			AuthURL:     "https://github.com/login/oauth/authorize",
+			TokenURL:    "https://github.com/login/oauth/access_token",
+			UserInfoURL: "https://api.github.com",
+			Scopes:      []string{"read:user", "user:email"},
+		}
+	default:
+		return OAuthProviderConfig{
+			Kind:   OAuthKindOIDC,
+			Scopes: []string{"openid", "email", "profile"},
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+type PasswordPolicyConfig struct {
+	MinLength int
+	// MaxLength is in bytes, bcrypt ignores everything past 72
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// HistorySize previous passwords of a user can not be used again
+	HistorySize int
+	// BreachedListPath points to a sorted SHA-1 pwned passwords list, empty disables the check
+	BreachedListPath string
+	// HashAlgorithm is argon2id or bcrypt, stored hashes of the other one are upgraded on login
+	HashAlgorithm string
+	// HashCost is the bcrypt cost
+	HashCost int
+	// Argon2Memory is in KiB
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+func NewPasswordPolicyConfig() (*PasswordPolicyConfig, error) {
+	config := PasswordPolicyConfig{
+		BreachedListPath: getEnv("PASSWORD_BREACHED_LIST_PATH", ""),
+		HashAlgorithm:    getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+	}
+
+	ints := []struct {
+		name     string
+		target   *int
+		fallback int
+	}{
+		{"PASSWORD_MIN_LENGTH", &config.MinLength, 8},
+		{"PASSWORD_MAX_LENGTH", &config.MaxLength, 72},
+		{"PASSWORD_HISTORY_SIZE", &config.HistorySize, 5},
+		{"PASSWORD_HASH_COST", &config.HashCost, 12},
+		{"PASSWORD_ARGON2_MEMORY", &config.Argon2Memory, 19456},
+		{"PASSWORD_ARGON2_ITERATIONS", &config.Argon2Iterations, 2},
+		{"PASSWORD_ARGON2_PARALLELISM", &config.Argon2Parallelism, 1},
+	}
+
+	for _, i := range ints {
+		value, err := getIntEnv(i.name, i.fallback)
+		if err != nil {
+			return nil, err
+		}
+		*i.target = value
+	}
+
+	bools := []struct {
+		name     string
+		target   *bool
+		fallback bool
+	}{
+		{"PASSWORD_REQUIRE_UPPER", &config.RequireUpper, true},
+		{"PASSWORD_REQUIRE_LOWER", &config.RequireLower, true},
+		{"PASSWORD_REQUIRE_DIGIT", &config.RequireDigit, true},
+		{"PASSWORD_REQUIRE_SYMBOL", &config.RequireSymbol, false},
+	}
+
+	for _, b := range bools {
+		value, err := getBoolEnv(b.name, b.fallback)
+		if err != nil {
+			return nil, err
+		}
+		*b.target = value
+	}
+
+	if config.MinLength > config.MaxLength {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH %d is greater than PASSWORD_MAX_LENGTH %d", config.MinLength, config.MaxLength)
+	}
+
+	return &config, nil
+}
+
+func getBoolEnv(name string, fallback bool) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	return result, nil
+}
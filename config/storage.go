@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	StorageDriverLocal = "local"
+	StorageDriverS3    = "s3"
+)
+
+// S3MaxSignedURLExpiry is the longest lifetime S3 accepts for a presigned URL
+const S3MaxSignedURLExpiry = time.Hour * 24 * 7
+
+type StorageConfig struct {
+	// Driver is "local" or "s3"
+	Driver string
+	// SigningKey signs the download URLs of the local driver
+	SigningKey string
+	// LocalRoot is the directory the local driver keeps files in and
+	// LocalURL where the app serves them
+	LocalRoot string
+	LocalURL  string
+	// S3Endpoint is the base URL of any S3 compatible service. PathStyle
+	// puts the bucket in the path instead of the host name, as MinIO expects.
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool
+}
+
+func NewStorageConfig() (*StorageConfig, error) {
+	pathStyle, err := getBoolEnv("STORAGE_S3_PATH_STYLE", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StorageConfig{
+		Driver:      getEnv("STORAGE_DRIVER", StorageDriverLocal),
+		SigningKey:  os.Getenv("STORAGE_SIGNING_KEY"),
+		LocalRoot:   getEnv("STORAGE_LOCAL_ROOT", "assets"),
+		LocalURL:    getEnv("STORAGE_LOCAL_URL", "/assets"),
+		S3Endpoint:  getEnv("STORAGE_S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3Region:    getEnv("STORAGE_S3_REGION", "us-east-1"),
+		S3Bucket:    os.Getenv("STORAGE_S3_BUCKET"),
+		S3AccessKey: os.Getenv("STORAGE_S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("STORAGE_S3_SECRET_KEY"),
+		S3PathStyle: pathStyle,
+	}, nil
+}
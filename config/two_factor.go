@@ -0,0 +1,36 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+
+	"blog/pkg/constants"
+)
+
+var ErrTOTPEncryptionKey = errors.New("TOTP_ENCRYPTION_KEY must be 32 bytes written as 64 hex characters")
+
+type TwoFactorConfig struct {
+	// EncryptionKey is the AES-256 key TOTP secrets are stored encrypted with
+	EncryptionKey []byte
+}
+
+// NewTwoFactorConfig refuses to start production without a valid key.
+// Elsewhere a missing or malformed key falls back to a fixed one, like the
+// default JWT secret.
+func NewTwoFactorConfig() (*TwoFactorConfig, error) {
+	key, err := hex.DecodeString(os.Getenv("TOTP_ENCRYPTION_KEY"))
+	if err == nil && len(key) == 32 {
+		return &TwoFactorConfig{EncryptionKey: key}, nil
+	}
+
+	if os.Getenv("APP_ENV") == constants.ENUM_RUN_PRODUCTION {
+		return nil, ErrTOTPEncryptionKey
+	}
+
+	log.Println(ErrTOTPEncryptionKey, "- using the development key")
+	fallback := sha256.Sum256([]byte("Template"))
+	return &TwoFactorConfig{EncryptionKey: fallback[:]}, nil
+}
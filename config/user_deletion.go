@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+)
+
+type UserDeletionConfig struct {
+	// GracePeriod is how long a deleted user can still be restored before
+	// the account is erased for good
+	GracePeriod time.Duration
+	// ErasureInterval is how often erasable users are looked for, 0 disables it
+	ErasureInterval time.Duration
+}
+
+func NewUserDeletionConfig() (*UserDeletionConfig, error) {
+	gracePeriod, err := getDurationEnv("USER_DELETION_GRACE_PERIOD", time.Hour*24*30)
+	if err != nil {
+		return nil, err
+	}
+
+	erasureInterval, err := getDurationEnv("USER_ERASURE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDeletionConfig{
+		GracePeriod:     gracePeriod,
+		ErasureInterval: erasureInterval,
+	}, nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"strings"
+)
+
+// WebAuthnConfig identifies this service as the relying party passkeys are
+// bound to. RPID is the domain, the origins are where the frontend is served.
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+}
+
+func NewWebAuthnConfig() *WebAuthnConfig {
+	var origins []string
+	for _, origin := range strings.Split(getEnv("WEBAUTHN_RP_ORIGINS", "http://localhost:3000"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return &WebAuthnConfig{
+		RPID:          getEnv("WEBAUTHN_RP_ID", "localhost"),
+		RPDisplayName: getEnv("WEBAUTHN_RP_NAME", getEnv("APP_NAME", "Template")),
+		RPOrigins:     origins,
+	}
+}
@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// LinkedIdentity ties an account at a social login provider to a user. The
+// provider's subject identifies the account, the email may change over time.
+type LinkedIdentity struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_linked_identity_subject" json:"provider"`
+	Subject  string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_linked_identity_subject" json:"subject"`
+	Email    string    `gorm:"type:varchar(255)" json:"email"`
+	User     User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
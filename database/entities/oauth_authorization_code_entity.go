@@ -0,0 +1,26 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthAuthorizationCode is issued by the authorize endpoint and exchanged
+// once for an access token. Only the hash of the code is stored.
+type OAuthAuthorizationCode struct {
+	ID                  uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CodeHash            string      `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ClientID            uuid.UUID   `gorm:"type:uuid;not null;index" json:"client_id"`
+	UserID              uuid.UUID   `gorm:"type:uuid;not null;index" json:"user_id"`
+	RedirectURI         string      `gorm:"type:text;not null" json:"redirect_uri"`
+	Scopes              []string    `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	CodeChallenge       string      `gorm:"type:varchar(128)" json:"-"`
+	CodeChallengeMethod string      `gorm:"type:varchar(10)" json:"-"`
+	ExpiresAt           time.Time   `gorm:"not null" json:"expires_at"`
+	ConsumedAt          *time.Time  `json:"consumed_at"`
+	Client              OAuthClient `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User                User        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
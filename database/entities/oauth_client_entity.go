@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// OAuthClient is an application allowed to authenticate users against this
+// service. Public clients (SPAs, mobile apps) have no secret and must use PKCE.
+type OAuthClient struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	ClientID     string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"client_id"`
+	SecretHash   string    `gorm:"type:varchar(64)" json:"-"`
+	Name         string    `gorm:"type:varchar(100);not null" json:"name"`
+	RedirectURIs []string  `gorm:"type:jsonb;serializer:json;not null" json:"redirect_uris"`
+	Scopes       []string  `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	GrantTypes   []string  `gorm:"type:jsonb;serializer:json;not null" json:"grant_types"`
+	Public       bool      `gorm:"default:false" json:"public"`
+
+	Timestamp
+}
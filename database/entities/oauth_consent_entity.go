@@ -0,0 +1,18 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// OAuthConsent records the scopes a user granted to a client, so the user is
+// only asked again when a client requests more
+type OAuthConsent struct {
+	ID       uuid.UUID   `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID   uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_oauth_consent_user_client" json:"user_id"`
+	ClientID uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_oauth_consent_user_client" json:"client_id"`
+	Scopes   []string    `gorm:"type:jsonb;serializer:json;not null" json:"scopes"`
+	Client   OAuthClient `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	User     User        `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
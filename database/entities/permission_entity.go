@@ -0,0 +1,14 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// Permission is a single action, named "<resource>:<action>" (e.g. "user:list")
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+
+	Timestamp
+}
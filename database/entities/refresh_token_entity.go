@@ -2,15 +2,29 @@ package entities
 
 import (
 	"time"
+
 	"github.com/google/uuid"
 )
 
+// RefreshToken only stores a keyed hash of the token handed to the client.
+// Each token belongs to a family: every rotation issues a new token in the
+// same family and marks the previous one as used. Presenting a used token again
+// means it has leaked, and the whole family is revoked. A family is what users
+// see as a session, so the client metadata is carried over on every rotation.
 type RefreshToken struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	UserID    uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
-	Token     string     `gorm:"type:text;not null" json:"token"`
-	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
-	User	  User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	ID               uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	FamilyID         uuid.UUID  `gorm:"type:uuid;not null;default:uuid_generate_v4();index" json:"family_id"`
+	TokenHash        string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt           *time.Time `json:"used_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	UserAgent        string     `gorm:"type:varchar(255)" json:"user_agent"`
+	IPAddress        string     `gorm:"type:varchar(45)" json:"ip_address"`
+	DeviceLabel      string     `gorm:"type:varchar(100)" json:"device_label"`
+	SessionStartedAt time.Time  `gorm:"not null;default:now()" json:"session_started_at"`
+	LastUsedAt       time.Time  `gorm:"not null;default:now()" json:"last_used_at"`
+	User             User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
 
 	Timestamp
-}
\ No newline at end of file
+}
@@ -0,0 +1,16 @@
+package entities
+
+import (
+	"github.com/google/uuid"
+)
+
+// Role is referenced by name from User.Role and grants its permissions to
+// every user holding it.
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	Name        string       `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"`
+	Description string       `gorm:"type:varchar(255)" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"permissions"`
+
+	Timestamp
+}
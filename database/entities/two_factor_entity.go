@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TwoFactorCredential holds the TOTP secret of a user, encrypted at rest.
+// Two-factor login is only enforced once the enrollment has been confirmed.
+// LastUsedStep keeps a code from being accepted twice.
+type TwoFactorCredential struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
+	SecretEncrypted string     `gorm:"type:text;not null" json:"-"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	LastUsedStep    int64      `gorm:"not null;default:0" json:"-"`
+	User            User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
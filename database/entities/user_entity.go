@@ -15,12 +15,17 @@ type User struct {
 	Password   string    `gorm:"type:varchar(255);not null" json:"-"`
 	Role       string    `gorm:"type:varchar(50);not null;default:'user'" json:"role"`
 	ImageUrl   string    `gorm:"type:varchar(255)" json:"image_url"`
+	// AvatarKey is the storage key of the uploaded avatar behind ImageUrl
+	AvatarKey  string    `gorm:"type:varchar(255)" json:"-"`
 	IsVerified bool      `gorm:"default:false" json:"is_verified"`
+	// DeletedAt makes deletes soft, the row is erased after a grace period
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	Timestamp
 }
 
-// BeforeCreate hook to hash password and set defaults
+// BeforeCreate hook to hash password and set defaults. Updates have no such
+// hook: a changed password is hashed by the caller and written as a column.
 func (u *User) BeforeCreate(_ *gorm.DB) (err error) {
 	// Hash password
 	if u.Password != "" {
@@ -42,15 +47,3 @@ func (u *User) BeforeCreate(_ *gorm.DB) (err error) {
 
 	return nil
 }
-
-// BeforeUpdate hook to handle password updates
-func (u *User) BeforeUpdate(_ *gorm.DB) (err error) {
-	// Only hash password if it has been changed
-	if u.Password != "" {
-		u.Password, err = helpers.HashPassword(u.Password)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
-}
\ No newline at end of file
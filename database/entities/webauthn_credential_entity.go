@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user. The
+// sign count is kept to notice cloned authenticators.
+type WebAuthnCredential struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CredentialID    []byte     `gorm:"type:bytea;uniqueIndex;not null" json:"-"`
+	PublicKey       []byte     `gorm:"type:bytea;not null" json:"-"`
+	AttestationType string     `gorm:"type:varchar(32)" json:"attestation_type"`
+	AAGUID          []byte     `gorm:"type:bytea" json:"-"`
+	SignCount       int64      `gorm:"not null;default:0" json:"sign_count"`
+	Transports      []string   `gorm:"type:jsonb;serializer:json;not null" json:"transports"`
+	BackupEligible  bool       `gorm:"default:false" json:"backup_eligible"`
+	BackupState     bool       `gorm:"default:false" json:"backup_state"`
+	Name            string     `gorm:"type:varchar(100)" json:"name"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	User            User       `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+
+	Timestamp
+}
@@ -6,12 +6,46 @@ import (
 )
 
 func Migrate(db *gorm.DB) error {
+	if err := invalidatePlaintextRefreshTokens(db); err != nil {
+		return err
+	}
+
 	if err := db.AutoMigrate(
+		&entities.Permission{},
+		&entities.Role{},
 		&entities.User{},
 		&entities.RefreshToken{},
+		&entities.OneTimeToken{},
+		&entities.TwoFactorCredential{},
+		&entities.RecoveryCode{},
+		&entities.LinkedIdentity{},
+		&entities.WebAuthnCredential{},
+		&entities.APIKey{},
+		&entities.PasswordHistory{},
+		&entities.PendingEmailChange{},
+		&entities.OAuthClient{},
+		&entities.OAuthAuthorizationCode{},
+		&entities.OAuthConsent{},
 	); err != nil {
 		return err
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// invalidatePlaintextRefreshTokens drops refresh tokens persisted before they
+// were stored hashed. Affected users simply have to log in again.
+func invalidatePlaintextRefreshTokens(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&entities.RefreshToken{}) || !migrator.HasColumn(&entities.RefreshToken{}, "token") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM refresh_tokens").Error; err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&entities.RefreshToken{}, "token")
+	})
+}
@@ -6,6 +6,14 @@ import (
 )
 
 func Seeder(db *gorm.DB) error {
+	if err := seeds.ListPermissionSeeder(db); err != nil {
+		return err
+	}
+
+	if err := seeds.ListRoleSeeder(db); err != nil {
+		return err
+	}
+
 	if err := seeds.ListUserSeeder(db); err != nil {
 		return err
 	}
@@ -0,0 +1,37 @@
+package seeds
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+func ListPermissionSeeder(db *gorm.DB) error {
+	jsonFile, err := os.Open("./database/seeders/json/permissions.json")
+	if err != nil {
+		return err
+	}
+	defer jsonFile.Close()
+
+	jsonData, err := io.ReadAll(jsonFile)
+	if err != nil {
+		return err
+	}
+
+	var listPermission []entities.Permission
+	if err := json.Unmarshal(jsonData, &listPermission); err != nil {
+		return err
+	}
+
+	for _, data := range listPermission {
+		var permission entities.Permission
+		if err := db.Where(entities.Permission{Name: data.Name}).FirstOrCreate(&permission, data).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package seeds
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"blog/database/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rolePermission is a row of the join table behind Role.Permissions
+type rolePermission struct {
+	RoleID       uuid.UUID
+	PermissionID uuid.UUID
+}
+
+func (rolePermission) TableName() string {
+	return "role_permissions"
+}
+
+type roleSeed struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// ListRoleSeeder creates the default roles and grants them their default
+// permissions. Every run grants the defaults that are missing, so permissions
+// added by later releases reach roles seeded before; links that exist are
+// never removed.
+func ListRoleSeeder(db *gorm.DB) error {
+	jsonFile, err := os.Open("./database/seeders/json/roles.json")
+	if err != nil {
+		return err
+	}
+	defer jsonFile.Close()
+
+	jsonData, err := io.ReadAll(jsonFile)
+	if err != nil {
+		return err
+	}
+
+	var listRole []roleSeed
+	if err := json.Unmarshal(jsonData, &listRole); err != nil {
+		return err
+	}
+
+	for _, data := range listRole {
+		var role entities.Role
+		err := db.Where(entities.Role{Name: data.Name}).
+			FirstOrCreate(&role, entities.Role{Name: data.Name, Description: data.Description}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(data.Permissions) == 0 {
+			continue
+		}
+
+		var permissions []entities.Permission
+		if err := db.Where("name IN ?", data.Permissions).Find(&permissions).Error; err != nil {
+			return err
+		}
+
+		if len(permissions) == 0 {
+			continue
+		}
+
+		links := make([]rolePermission, 0, len(permissions))
+		for _, permission := range permissions {
+			links = append(links, rolePermission{RoleID: role.ID, PermissionID: permission.ID})
+		}
+
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -3,13 +3,18 @@ package middlewares
 import (
 	"net/http"
 	"strings"
+
+	apiKeyService "blog/modules/apikey/service"
 	"blog/modules/auth/service"
 	"blog/modules/user/dto"
+	"blog/pkg/constants"
 	"blog/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
-func Authenticate(jwtService service.JWTService) gin.HandlerFunc {
+const claimsKey = "claims"
+
+func Authenticate(jwtService service.JWTService, revocationService service.TokenRevocationService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 
@@ -26,28 +31,76 @@ func Authenticate(jwtService service.JWTService) gin.HandlerFunc {
 		}
 
 		authHeader = strings.Replace(authHeader, "Bearer ", "", -1)
-		token, err := jwtService.ValidateToken(authHeader)
+		claims, err := jwtService.ValidateToken(authHeader)
 		if err != nil {
 			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_TOKEN_NOT_VALID, nil)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
 			return
 		}
 
-		if !token.Valid {
+		if claims.Purpose != constants.ENUM_TOKEN_PURPOSE_ACCESS || claims.UserID == "" {
 			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
 			return
 		}
 
-		userId, err := jwtService.GetUserIDByToken(authHeader)
+		revoked, err := revocationService.IsRevoked(ctx.Request.Context(), claims)
 		if err != nil {
 			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+			return
+		}
+
+		if revoked {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_TOKEN_REVOKED, nil)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
 			return
 		}
 
 		ctx.Set("token", authHeader)
-		ctx.Set("user_id", userId)
+		ctx.Set("user_id", claims.UserID)
+		ctx.Set(claimsKey, claims)
+		ctx.Next()
+	}
+}
+
+// AuthenticateWithAPIKey accepts an API key in the X-API-Key header as well as
+// a Bearer access token. Either way user_id and the claims are set, so the
+// handlers and permission checks after it work the same.
+func AuthenticateWithAPIKey(
+	jwtService service.JWTService,
+	revocationService service.TokenRevocationService,
+	apiKeyService apiKeyService.APIKeyService,
+) gin.HandlerFunc {
+	authenticate := Authenticate(jwtService, revocationService)
+
+	return func(ctx *gin.Context) {
+		apiKey := ctx.GetHeader("X-API-Key")
+		if apiKey == "" {
+			authenticate(ctx)
+			return
+		}
+
+		claims, err := apiKeyService.Authenticate(ctx.Request.Context(), apiKey, ctx.ClientIP())
+		if err != nil {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+
+		ctx.Set("user_id", claims.UserID)
+		ctx.Set(claimsKey, claims)
 		ctx.Next()
 	}
-}
\ No newline at end of file
+}
+
+// GetClaims returns the claims of the access token accepted by Authenticate
+func GetClaims(ctx *gin.Context) (*service.JWTCustomClaim, bool) {
+	value, ok := ctx.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := value.(*service.JWTCustomClaim)
+	return claims, ok
+}
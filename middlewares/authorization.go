@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"net/http"
+	"slices"
+
+	rbacService "blog/modules/rbac/service"
+	"blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets the request through when the authenticated user has one of
+// the given roles. It must be chained after Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := GetClaims(ctx)
+		if !ok {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_TOKEN_NOT_FOUND, nil)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+
+		if !slices.Contains(roles, claims.Role) {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequirePermission lets the request through when the role of the
+// authenticated user grants every given permission, and a scoped API key
+// includes them. It must be chained after Authenticate.
+func RequirePermission(rbacService rbacService.RBACService, permissions ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		claims, ok := GetClaims(ctx)
+		if !ok {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_TOKEN_NOT_FOUND, nil)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+
+		if !claims.HasScopes(permissions...) {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+			return
+		}
+
+		allowed, err := rbacService.HasPermissions(ctx.Request.Context(), claims.Role, permissions...)
+		if err != nil {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, response)
+			return
+		}
+
+		if !allowed {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+			return
+		}
+
+		ctx.Next()
+	}
+}
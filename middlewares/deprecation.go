@@ -0,0 +1,14 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks a route as deprecated and points clients to its successor
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", "true")
+		ctx.Header("Link", "<"+successor+">; rel=\"successor-version\"")
+		ctx.Next()
+	}
+}
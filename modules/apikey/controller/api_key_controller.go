@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/apikey/dto"
+	"blog/modules/apikey/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	APIKeyController interface {
+		Create(ctx *gin.Context)
+		List(ctx *gin.Context)
+		Rotate(ctx *gin.Context)
+		Revoke(ctx *gin.Context)
+	}
+
+	apiKeyController struct {
+		apiKeyService service.APIKeyService
+	}
+)
+
+func NewAPIKeyController(aks service.APIKeyService) APIKeyController {
+	return &apiKeyController{
+		apiKeyService: aks,
+	}
+}
+
+func (c *apiKeyController) Create(ctx *gin.Context) {
+	var req dto.APIKeyCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.apiKeyService.Create(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_API_KEY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_API_KEY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *apiKeyController) List(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.apiKeyService.List(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_API_KEYS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_API_KEYS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *apiKeyController) Rotate(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.apiKeyService.Rotate(ctx.Request.Context(), userId, ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ROTATE_API_KEY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ROTATE_API_KEY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *apiKeyController) Revoke(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	if err := c.apiKeyService.Revoke(ctx.Request.Context(), userId, ctx.Param("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REVOKE_API_KEY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_API_KEY, nil)
+	ctx.JSON(http.StatusOK, res)
+}
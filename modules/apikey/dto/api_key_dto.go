@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_CREATE_API_KEY = "failed create api key"
+	MESSAGE_FAILED_GET_API_KEYS   = "failed get api keys"
+	MESSAGE_FAILED_ROTATE_API_KEY = "failed rotate api key"
+	MESSAGE_FAILED_REVOKE_API_KEY = "failed revoke api key"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_API_KEY = "success create api key"
+	MESSAGE_SUCCESS_GET_API_KEYS   = "success get api keys"
+	MESSAGE_SUCCESS_ROTATE_API_KEY = "success rotate api key"
+	MESSAGE_SUCCESS_REVOKE_API_KEY = "success revoke api key"
+)
+
+var (
+	ErrAPIKeyNotFound        = errors.New("api key not found")
+	ErrAPIKeyInvalid         = errors.New("api key invalid")
+	ErrAPIKeyExpired         = errors.New("api key expired")
+	ErrAPIKeyRevoked         = errors.New("api key revoked")
+	ErrAPIKeyExpiryInPast    = errors.New("api key expiry must be in the future")
+	ErrAPIKeyScopeNotAllowed = errors.New("api key scopes must be permissions of your role")
+)
+
+type (
+	APIKeyCreateRequest struct {
+		Name      string     `json:"name" binding:"required,min=1,max=100"`
+		Scopes    []string   `json:"scopes" binding:"omitempty,dive,required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	APIKeyResponse struct {
+		ID         string     `json:"id"`
+		Name       string     `json:"name"`
+		Prefix     string     `json:"prefix"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		LastUsedIP string     `json:"last_used_ip"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	// APIKeyCreatedResponse carries the key itself, which is only shown once
+	APIKeyCreatedResponse struct {
+		APIKeyResponse
+		Key string `json:"key"`
+	}
+)
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, key entities.APIKey) (entities.APIKey, error)
+	FindByUserID(ctx context.Context, tx *gorm.DB, userId string) ([]entities.APIKey, error)
+	CountByUserID(ctx context.Context, tx *gorm.DB, userId string) (int64, error)
+	FindByID(ctx context.Context, tx *gorm.DB, userId string, id string) (entities.APIKey, error)
+	FindByHash(ctx context.Context, tx *gorm.DB, keyHash string) (entities.APIKey, error)
+	ReplaceKey(ctx context.Context, tx *gorm.DB, id string, prefix string, keyHash string) error
+	Revoke(ctx context.Context, tx *gorm.DB, userId string, id string) error
+	TouchLastUsed(ctx context.Context, tx *gorm.DB, id string, ip string) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, tx *gorm.DB, key entities.APIKey) (entities.APIKey, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&key).Error; err != nil {
+		return entities.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindByUserID(ctx context.Context, tx *gorm.DB, userId string) ([]entities.APIKey, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var keys []entities.APIKey
+	if err := tx.WithContext(ctx).Where("user_id = ?", userId).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) CountByUserID(ctx context.Context, tx *gorm.DB, userId string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).Model(&entities.APIKey{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, tx *gorm.DB, userId string, id string) (entities.APIKey, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var key entities.APIKey
+	if err := tx.WithContext(ctx).Where("id = ? AND user_id = ?", id, userId).Take(&key).Error; err != nil {
+		return entities.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, tx *gorm.DB, keyHash string) (entities.APIKey, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var key entities.APIKey
+	if err := tx.WithContext(ctx).Preload("User").Where("key_hash = ?", keyHash).Take(&key).Error; err != nil {
+		return entities.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// ReplaceKey swaps the secret of a key that has not been revoked, the old
+// secret stops working at once
+func (r *apiKeyRepository) ReplaceKey(ctx context.Context, tx *gorm.DB, id string, prefix string, keyHash string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]any{
+			"prefix":   prefix,
+			"key_hash": keyHash,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, tx *gorm.DB, userId string, id string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, tx *gorm.DB, id string, ip string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entities.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"last_used_at": time.Now(),
+			"last_used_ip": ip,
+		}).Error
+}
@@ -0,0 +1,25 @@
+package apikey
+
+import (
+	"blog/middlewares"
+	"blog/modules/apikey/controller"
+	authService "blog/modules/auth/service"
+	"blog/pkg/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func RegisterRoutes(router *gin.Engine, injector *do.Injector) {
+	apiKeyController := do.MustInvoke[controller.APIKeyController](injector)
+	jwtService := do.MustInvokeNamed[authService.JWTService](injector, constants.JWTService)
+	revocationService := do.MustInvokeNamed[authService.TokenRevocationService](injector, constants.TokenRevocationService)
+
+	// Keys are managed with an access token only, a key cannot mint other keys
+	apiKeyRoutes := router.Group("/api/v1/api-keys", middlewares.Authenticate(jwtService, revocationService))
+	{
+		apiKeyRoutes.GET("", apiKeyController.List)
+		apiKeyRoutes.POST("", apiKeyController.Create)
+		apiKeyRoutes.POST("/:id/rotate", apiKeyController.Rotate)
+		apiKeyRoutes.DELETE("/:id", apiKeyController.Revoke)
+	}
+}
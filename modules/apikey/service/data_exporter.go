@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"blog/modules/apikey/repository"
+	"blog/pkg/export"
+	"gorm.io/gorm"
+)
+
+// NewAPIKeyExporter exports the API keys of a user, without their hashes
+func NewAPIKeyExporter(apiKeyRepo repository.APIKeyRepository, db *gorm.DB) export.Exporter {
+	return export.NewExporter(
+		"api_keys",
+		func(ctx context.Context, userId string) (int64, error) {
+			return apiKeyRepo.CountByUserID(ctx, db, userId)
+		},
+		func(ctx context.Context, userId string) ([]export.Record, error) {
+			keys, err := apiKeyRepo.FindByUserID(ctx, db, userId)
+			if err != nil {
+				return nil, err
+			}
+			return export.ToRecords(keys)
+		},
+	)
+}
@@ -1,8 +1,11 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
+	"blog/middlewares"
 	"blog/modules/auth/dto"
 	"blog/modules/auth/service"
 	"blog/modules/auth/validation"
@@ -18,16 +21,23 @@ type (
 	AuthController interface {
 		Register(ctx *gin.Context)
 		Login(ctx *gin.Context)
+		LoginTwoFactor(ctx *gin.Context)
 		RefreshToken(ctx *gin.Context)
 		Logout(ctx *gin.Context)
 		SendVerificationEmail(ctx *gin.Context)
 		VerifyEmail(ctx *gin.Context)
+		SendMagicLink(ctx *gin.Context)
+		LoginMagicLink(ctx *gin.Context)
 		SendPasswordReset(ctx *gin.Context)
 		ResetPassword(ctx *gin.Context)
+		ChangePassword(ctx *gin.Context)
+		JWKS(ctx *gin.Context)
+		UnlockAccount(ctx *gin.Context)
 	}
 
 	authController struct {
 		authService    service.AuthService
+		jwtService     service.JWTService
 		authValidation *validation.AuthValidation
 		db             *gorm.DB
 	}
@@ -35,9 +45,11 @@ type (
 
 func NewAuthController(injector *do.Injector, as service.AuthService) AuthController {
 	db := do.MustInvokeNamed[*gorm.DB](injector, constants.DB)
+	jwtService := do.MustInvokeNamed[service.JWTService](injector, constants.JWTService)
 	authValidation := validation.NewAuthValidation()
 	return &authController{
 		authService:    as,
+		jwtService:     jwtService,
 		authValidation: authValidation,
 		db:             db,
 	}
@@ -84,10 +96,43 @@ func (c *authController) Login(ctx *gin.Context) {
 		return
 	}
 
-	result, err := c.authService.Login(ctx.Request.Context(), req)
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), req.DeviceLabel)
+	result, err := c.authService.Login(ctx.Request.Context(), req, session)
 	if err != nil {
+		status := http.StatusBadRequest
+		var retryErr *dto.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(retryErr.Seconds()))
+			status = http.StatusTooManyRequests
+		}
+
 		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_LOGIN, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(status, res)
+		return
+	}
+
+	message := userDto.MESSAGE_SUCCESS_LOGIN
+	if result.TwoFactorRequired {
+		message = dto.MESSAGE_SUCCESS_LOGIN_TWO_FACTOR_REQUIRED
+	}
+
+	res := utils.BuildResponseSuccess(message, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *authController) LoginTwoFactor(ctx *gin.Context) {
+	var req dto.TwoFactorLoginRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), req.DeviceLabel)
+	result, err := c.authService.LoginTwoFactor(ctx.Request.Context(), req, session)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_LOGIN_TWO_FACTOR, err.Error(), nil)
+		ctx.JSON(http.StatusUnauthorized, res)
 		return
 	}
 
@@ -103,7 +148,8 @@ func (c *authController) RefreshToken(ctx *gin.Context) {
 		return
 	}
 
-	result, err := c.authService.RefreshToken(ctx.Request.Context(), req)
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), "")
+	result, err := c.authService.RefreshToken(ctx.Request.Context(), req, session)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REFRESH_TOKEN, err.Error(), nil)
 		ctx.JSON(http.StatusUnauthorized, res)
@@ -116,8 +162,9 @@ func (c *authController) RefreshToken(ctx *gin.Context) {
 
 func (c *authController) Logout(ctx *gin.Context) {
 	userId := ctx.MustGet("user_id").(string)
+	token := ctx.MustGet("token").(string)
 
-	err := c.authService.Logout(ctx.Request.Context(), userId)
+	err := c.authService.Logout(ctx.Request.Context(), userId, token)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_LOGOUT, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
@@ -138,7 +185,7 @@ func (c *authController) SendVerificationEmail(ctx *gin.Context) {
 
 	err := c.authService.SendVerificationEmail(ctx.Request.Context(), req)
 	if err != nil {
-		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_PROCESS_REQUEST, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
@@ -166,6 +213,57 @@ func (c *authController) VerifyEmail(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+func (c *authController) SendMagicLink(ctx *gin.Context) {
+	var req dto.SendMagicLinkRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), "")
+	if err := c.authService.SendMagicLink(ctx.Request.Context(), req, session); err != nil {
+		status := http.StatusInternalServerError
+		var retryErr *dto.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(retryErr.Seconds()))
+			status = http.StatusTooManyRequests
+		}
+
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_SEND_MAGIC_LINK, err.Error(), nil)
+		ctx.AbortWithStatusJSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_SEND_MAGIC_LINK, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *authController) LoginMagicLink(ctx *gin.Context) {
+	var req dto.MagicLinkLoginRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), req.DeviceLabel)
+	result, err := c.authService.LoginMagicLink(ctx.Request.Context(), req, session)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_LOGIN_MAGIC_LINK, err.Error(), nil)
+		ctx.JSON(http.StatusUnauthorized, res)
+		return
+	}
+
+	message := userDto.MESSAGE_SUCCESS_LOGIN
+	if result.TwoFactorRequired {
+		message = dto.MESSAGE_SUCCESS_LOGIN_TWO_FACTOR_REQUIRED
+	}
+
+	res := utils.BuildResponseSuccess(message, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
 func (c *authController) SendPasswordReset(ctx *gin.Context) {
 	var req dto.SendPasswordResetRequest
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -202,4 +300,55 @@ func (c *authController) ResetPassword(ctx *gin.Context) {
 
 	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_RESET_PASSWORD, nil)
 	ctx.JSON(http.StatusOK, res)
-}
\ No newline at end of file
+}
+
+func (c *authController) ChangePassword(ctx *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+
+	var sessionId string
+	if claims, ok := middlewares.GetClaims(ctx); ok {
+		sessionId = claims.SessionID
+	}
+
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), "")
+	err := c.authService.ChangePassword(ctx.Request.Context(), userId, sessionId, req, session)
+	if err != nil {
+		status := http.StatusBadRequest
+		var retryErr *dto.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(retryErr.Seconds()))
+			status = http.StatusTooManyRequests
+		}
+
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CHANGE_PASSWORD, err.Error(), nil)
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CHANGE_PASSWORD, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// JWKS publishes the verification keys as a plain RFC 7517 key set
+func (c *authController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.jwtService.JWKS())
+}
+
+func (c *authController) UnlockAccount(ctx *gin.Context) {
+	if err := c.authService.UnlockAccount(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UNLOCK_ACCOUNT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UNLOCK_ACCOUNT, nil)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"blog/modules/auth/dto"
+	"blog/modules/auth/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/constants"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	OAuthController interface {
+		Authorize(ctx *gin.Context)
+		Callback(ctx *gin.Context)
+	}
+
+	oauthController struct {
+		oauthService service.OAuthService
+	}
+)
+
+func NewOAuthController(oas service.OAuthService) OAuthController {
+	return &oauthController{
+		oauthService: oas,
+	}
+}
+
+func (c *oauthController) Authorize(ctx *gin.Context) {
+	result, err := c.oauthService.AuthorizationURL(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_OAUTH_AUTHORIZE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	setStateCookie(ctx, result.StateBinding, int(result.ExpiresIn.Seconds()))
+	ctx.Redirect(http.StatusFound, result.URL)
+}
+
+func (c *oauthController) Callback(ctx *gin.Context) {
+	var req dto.OAuthCallbackRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_OAUTH_CALLBACK, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	// The cookie is spent with the state, whatever the outcome
+	req.StateBinding, _ = ctx.Cookie(dto.OAUTH_STATE_COOKIE)
+	setStateCookie(ctx, "", -1)
+
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), "")
+	result, err := c.oauthService.Callback(ctx.Request.Context(), ctx.Param("provider"), req, session)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_OAUTH_CALLBACK, err.Error(), nil)
+		ctx.JSON(http.StatusUnauthorized, res)
+		return
+	}
+
+	message := userDto.MESSAGE_SUCCESS_LOGIN
+	if result.TwoFactorRequired {
+		message = dto.MESSAGE_SUCCESS_LOGIN_TWO_FACTOR_REQUIRED
+	}
+
+	res := utils.BuildResponseSuccess(message, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// setStateCookie scopes the cookie to the social login routes. Lax lets it
+// ride along on the top-level redirect back from the provider.
+func setStateCookie(ctx *gin.Context, value string, maxAge int) {
+	secure := ctx.Request.TLS != nil || os.Getenv("APP_ENV") == constants.ENUM_RUN_PRODUCTION
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(dto.OAUTH_STATE_COOKIE, value, maxAge, oauthCookiePath(ctx), "", secure, true)
+}
+
+// oauthCookiePath is the parent of /oauth/:provider and /oauth/:provider/callback
+func oauthCookiePath(ctx *gin.Context) string {
+	path, _, _ := strings.Cut(ctx.FullPath(), "/:provider")
+	return path + "/"
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/auth/dto"
+	"blog/modules/auth/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	PasskeyController interface {
+		BeginRegistration(ctx *gin.Context)
+		FinishRegistration(ctx *gin.Context)
+		ListPasskeys(ctx *gin.Context)
+		DeletePasskey(ctx *gin.Context)
+		BeginLogin(ctx *gin.Context)
+		FinishLogin(ctx *gin.Context)
+	}
+
+	passkeyController struct {
+		passkeyService service.PasskeyService
+	}
+)
+
+func NewPasskeyController(ps service.PasskeyService) PasskeyController {
+	return &passkeyController{
+		passkeyService: ps,
+	}
+}
+
+func (c *passkeyController) BeginRegistration(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.passkeyService.BeginRegistration(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_BEGIN_PASSKEY_REGISTRATION, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_BEGIN_PASSKEY_REGISTRATION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *passkeyController) FinishRegistration(ctx *gin.Context) {
+	var req dto.PasskeyRegisterRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.passkeyService.FinishRegistration(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REGISTER_PASSKEY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REGISTER_PASSKEY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *passkeyController) ListPasskeys(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.passkeyService.ListPasskeys(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PASSKEYS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_PASSKEYS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *passkeyController) DeletePasskey(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	if err := c.passkeyService.DeletePasskey(ctx.Request.Context(), userId, ctx.Param("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_PASSKEY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_PASSKEY, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *passkeyController) BeginLogin(ctx *gin.Context) {
+	var req dto.PasskeyLoginBeginRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.passkeyService.BeginLogin(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_BEGIN_PASSKEY_LOGIN, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_BEGIN_PASSKEY_LOGIN, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *passkeyController) FinishLogin(ctx *gin.Context) {
+	var req dto.PasskeyLoginFinishRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	session := dto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), req.DeviceLabel)
+	result, err := c.passkeyService.FinishLogin(ctx.Request.Context(), req, session)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_LOGIN_PASSKEY, err.Error(), nil)
+		ctx.JSON(http.StatusUnauthorized, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(userDto.MESSAGE_SUCCESS_LOGIN, result)
+	ctx.JSON(http.StatusOK, res)
+}
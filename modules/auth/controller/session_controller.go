@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"blog/middlewares"
+	"blog/modules/auth/dto"
+	"blog/modules/auth/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	SessionController interface {
+		ListSessions(ctx *gin.Context)
+		RevokeSession(ctx *gin.Context)
+		RevokeOtherSessions(ctx *gin.Context)
+	}
+
+	sessionController struct {
+		sessionService service.SessionService
+	}
+)
+
+func NewSessionController(ss service.SessionService) SessionController {
+	return &sessionController{
+		sessionService: ss,
+	}
+}
+
+func (c *sessionController) ListSessions(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	var currentSessionId string
+	if claims, ok := middlewares.GetClaims(ctx); ok {
+		currentSessionId = claims.SessionID
+	}
+
+	result, err := c.sessionService.ListSessions(ctx.Request.Context(), userId, currentSessionId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SESSIONS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SESSIONS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *sessionController) RevokeSession(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	err := c.sessionService.RevokeSession(ctx.Request.Context(), userId, ctx.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, dto.ErrSessionNotFound) {
+			status = http.StatusNotFound
+		}
+
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REVOKE_SESSION, err.Error(), nil)
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_SESSION, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *sessionController) RevokeOtherSessions(ctx *gin.Context) {
+	var req dto.RevokeOtherSessionsRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	err := c.sessionService.RevokeOtherSessions(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REVOKE_OTHER_SESSIONS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_OTHER_SESSIONS, nil)
+	ctx.JSON(http.StatusOK, res)
+}
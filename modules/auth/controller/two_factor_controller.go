@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/auth/dto"
+	"blog/modules/auth/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	TwoFactorController interface {
+		Enroll(ctx *gin.Context)
+		Confirm(ctx *gin.Context)
+		Disable(ctx *gin.Context)
+		RegenerateRecoveryCodes(ctx *gin.Context)
+	}
+
+	twoFactorController struct {
+		twoFactorService service.TwoFactorService
+	}
+)
+
+func NewTwoFactorController(tfs service.TwoFactorService) TwoFactorController {
+	return &twoFactorController{
+		twoFactorService: tfs,
+	}
+}
+
+func (c *twoFactorController) Enroll(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.twoFactorService.Enroll(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ENROLL_TWO_FACTOR, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ENROLL_TWO_FACTOR, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *twoFactorController) Confirm(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.twoFactorService.Confirm(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CONFIRM_TWO_FACTOR, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CONFIRM_TWO_FACTOR, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *twoFactorController) Disable(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	if err := c.twoFactorService.Disable(ctx.Request.Context(), userId, req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DISABLE_TWO_FACTOR, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DISABLE_TWO_FACTOR, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *twoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.twoFactorService.RegenerateRecoveryCodes(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REGENERATE_RECOVERY_CODES, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REGENERATE_RECOVERY_CODES, result)
+	ctx.JSON(http.StatusOK, res)
+}
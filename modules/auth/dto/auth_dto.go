@@ -2,6 +2,8 @@ package dto
 
 import (
 	"errors"
+	"math"
+	"time"
 )
 
 const (
@@ -13,15 +15,44 @@ const (
 	MESSAGE_SUCCESS_SEND_PASSWORD_RESET = "success send password reset"
 	MESSAGE_FAILED_RESET_PASSWORD       = "failed reset password"
 	MESSAGE_SUCCESS_RESET_PASSWORD      = "success reset password"
+	MESSAGE_FAILED_CHANGE_PASSWORD      = "failed change password"
+	MESSAGE_SUCCESS_CHANGE_PASSWORD     = "success change password"
+	MESSAGE_FAILED_UNLOCK_ACCOUNT       = "failed unlock account"
+	MESSAGE_SUCCESS_UNLOCK_ACCOUNT      = "success unlock account"
 )
 
 var (
 	ErrRefreshTokenNotFound = errors.New("refresh token not found")
 	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenRevoked  = errors.New("refresh token revoked")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected")
 	ErrInvalidCredentials   = errors.New("invalid credentials")
 	ErrPasswordResetToken   = errors.New("password reset token invalid")
+	ErrCurrentPassword      = errors.New("current password is incorrect")
+	ErrAccountLocked        = errors.New("account locked, try again later")
+	ErrTooManyLoginAttempts = errors.New("too many login attempts, try again later")
 )
 
+// RetryAfterError is returned when a request is refused for a while, such as
+// a login on a locked account. It unwraps to the underlying error.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// Seconds returns the wait rounded up, as sent in the Retry-After header
+func (e *RetryAfterError) Seconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
 type (
 	RefreshTokenRequest struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
@@ -42,6 +73,11 @@ type (
 		NewPassword string `json:"new_password" binding:"required,min=8"`
 	}
 
+	ChangePasswordRequest struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=8"`
+	}
+
 	SendVerificationEmailRequest struct {
 		Email string `json:"email" binding:"required,email"`
 	}
@@ -49,4 +85,4 @@ type (
 	VerifyEmailRequest struct {
 		Token string `json:"token" binding:"required"`
 	}
-)
\ No newline at end of file
+)
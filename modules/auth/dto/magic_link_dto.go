@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	MESSAGE_FAILED_SEND_MAGIC_LINK  = "failed send login link"
+	MESSAGE_SUCCESS_SEND_MAGIC_LINK = "success send login link"
+	MESSAGE_FAILED_LOGIN_MAGIC_LINK = "failed login with login link"
+)
+
+var (
+	ErrMagicLinkInvalid         = errors.New("login link invalid or expired")
+	ErrTooManyMagicLinkRequests = errors.New("too many login link requests, try again later")
+)
+
+type (
+	SendMagicLinkRequest struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	MagicLinkLoginRequest struct {
+		Token       string `json:"token" binding:"required"`
+		DeviceLabel string `json:"device_label" binding:"omitempty,max=100"`
+	}
+)
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	MESSAGE_FAILED_OAUTH_AUTHORIZE = "failed start social login"
+	MESSAGE_FAILED_OAUTH_CALLBACK  = "failed social login"
+
+	// OAUTH_STATE_COOKIE binds a social login to the browser that started it
+	OAUTH_STATE_COOKIE = "oauth_state"
+)
+
+var (
+	ErrOAuthProviderNotFound  = errors.New("oauth provider not found")
+	ErrOAuthStateInvalid      = errors.New("oauth state invalid or expired")
+	ErrOAuthEmailNotVerified  = errors.New("email not verified by the provider")
+	ErrOAuthAccountUnverified = errors.New("verify the email of the existing account before signing in with this provider")
+)
+
+type (
+	// OAuthAuthorization is where to send the browser and the value the
+	// browser keeps in OAUTH_STATE_COOKIE until the provider redirects back
+	OAuthAuthorization struct {
+		URL          string
+		StateBinding string
+		ExpiresIn    time.Duration
+	}
+
+	// OAuthCallbackRequest carries the query of the redirect back; StateBinding
+	// is read from OAUTH_STATE_COOKIE
+	OAuthCallbackRequest struct {
+		Code         string `form:"code" binding:"required"`
+		State        string `form:"state" binding:"required"`
+		StateBinding string `form:"-"`
+	}
+)
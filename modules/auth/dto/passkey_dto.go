@@ -0,0 +1,73 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+const (
+	MESSAGE_FAILED_BEGIN_PASSKEY_REGISTRATION  = "failed start passkey registration"
+	MESSAGE_SUCCESS_BEGIN_PASSKEY_REGISTRATION = "success start passkey registration"
+	MESSAGE_FAILED_REGISTER_PASSKEY            = "failed register passkey"
+	MESSAGE_SUCCESS_REGISTER_PASSKEY           = "success register passkey"
+	MESSAGE_FAILED_GET_PASSKEYS                = "failed get passkeys"
+	MESSAGE_SUCCESS_GET_PASSKEYS               = "success get passkeys"
+	MESSAGE_FAILED_DELETE_PASSKEY              = "failed delete passkey"
+	MESSAGE_SUCCESS_DELETE_PASSKEY             = "success delete passkey"
+	MESSAGE_FAILED_BEGIN_PASSKEY_LOGIN         = "failed start passkey login"
+	MESSAGE_SUCCESS_BEGIN_PASSKEY_LOGIN        = "success start passkey login"
+	MESSAGE_FAILED_LOGIN_PASSKEY               = "failed login with passkey"
+)
+
+var (
+	ErrPasskeyCeremonyInvalid = errors.New("passkey ceremony invalid or expired")
+	ErrPasskeyNotFound        = errors.New("passkey not found")
+	ErrPasskeyCloneDetected   = errors.New("passkey sign count went backwards, the authenticator may be cloned")
+)
+
+type (
+	// PasskeyRegistrationResponse holds the options passed to
+	// navigator.credentials.create()
+	PasskeyRegistrationResponse struct {
+		Options *protocol.CredentialCreation `json:"options"`
+	}
+
+	// PasskeyRegisterRequest carries the PublicKeyCredential returned by
+	// navigator.credentials.create(), serialized as JSON
+	PasskeyRegisterRequest struct {
+		Name       string          `json:"name" binding:"omitempty,max=100"`
+		Credential json.RawMessage `json:"credential" binding:"required"`
+	}
+
+	// PasskeyLoginBeginRequest may name the account for older clients. It does
+	// not narrow the challenge, any discoverable passkey of this site is used.
+	PasskeyLoginBeginRequest struct {
+		Email string `json:"email" binding:"omitempty,email"`
+	}
+
+	// PasskeyLoginBeginResponse holds the options passed to
+	// navigator.credentials.get() and the ceremony to send back with the result
+	PasskeyLoginBeginResponse struct {
+		SessionID string                        `json:"session_id"`
+		Options   *protocol.CredentialAssertion `json:"options"`
+	}
+
+	PasskeyLoginFinishRequest struct {
+		SessionID   string          `json:"session_id" binding:"required"`
+		Credential  json.RawMessage `json:"credential" binding:"required"`
+		DeviceLabel string          `json:"device_label" binding:"omitempty,max=100"`
+	}
+
+	PasskeyResponse struct {
+		ID             string     `json:"id"`
+		Name           string     `json:"name"`
+		Transports     []string   `json:"transports"`
+		BackupEligible bool       `json:"backup_eligible"`
+		BackupState    bool       `json:"backup_state"`
+		CreatedAt      time.Time  `json:"created_at"`
+		LastUsedAt     *time.Time `json:"last_used_at"`
+	}
+)
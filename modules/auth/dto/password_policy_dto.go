@@ -0,0 +1,14 @@
+package dto
+
+import (
+	"errors"
+)
+
+var (
+	ErrPasswordTooShort     = errors.New("password is too short")
+	ErrPasswordTooLong      = errors.New("password is too long")
+	ErrPasswordMissingClass = errors.New("password is missing a required character type")
+	ErrPasswordPersonalInfo = errors.New("password must not contain your name or email")
+	ErrPasswordBreached     = errors.New("password has appeared in a data breach, choose another one")
+	ErrPasswordRecentlyUsed = errors.New("password was used recently, choose another one")
+)
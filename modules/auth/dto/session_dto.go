@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"blog/pkg/helpers"
+)
+
+const (
+	MESSAGE_FAILED_GET_SESSIONS           = "failed get sessions"
+	MESSAGE_SUCCESS_GET_SESSIONS          = "success get sessions"
+	MESSAGE_FAILED_REVOKE_SESSION         = "failed revoke session"
+	MESSAGE_SUCCESS_REVOKE_SESSION        = "success revoke session"
+	MESSAGE_FAILED_REVOKE_OTHER_SESSIONS  = "failed revoke other sessions"
+	MESSAGE_SUCCESS_REVOKE_OTHER_SESSIONS = "success revoke other sessions"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+type (
+	// SessionInfo describes the client a refresh token is issued to
+	SessionInfo struct {
+		UserAgent   string
+		IPAddress   string
+		DeviceLabel string
+	}
+
+	SessionResponse struct {
+		ID          string    `json:"id"`
+		DeviceLabel string    `json:"device_label"`
+		UserAgent   string    `json:"user_agent"`
+		IPAddress   string    `json:"ip_address"`
+		CreatedAt   time.Time `json:"created_at"`
+		LastUsedAt  time.Time `json:"last_used_at"`
+		ExpiresAt   time.Time `json:"expires_at"`
+		IsCurrent   bool      `json:"is_current"`
+	}
+
+	RevokeOtherSessionsRequest struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+)
+
+// NewSessionInfo builds a SessionInfo, trimming values to their column sizes
+func NewSessionInfo(userAgent string, ipAddress string, deviceLabel string) SessionInfo {
+	userAgent = helpers.TruncateRunes(userAgent, 255)
+
+	if deviceLabel == "" {
+		deviceLabel = userAgent
+	}
+
+	deviceLabel = helpers.TruncateRunes(deviceLabel, 100)
+
+	return SessionInfo{
+		UserAgent:   userAgent,
+		IPAddress:   ipAddress,
+		DeviceLabel: deviceLabel,
+	}
+}
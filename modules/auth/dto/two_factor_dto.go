@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	MESSAGE_FAILED_ENROLL_TWO_FACTOR          = "failed enroll two-factor authentication"
+	MESSAGE_SUCCESS_ENROLL_TWO_FACTOR         = "success enroll two-factor authentication"
+	MESSAGE_FAILED_CONFIRM_TWO_FACTOR         = "failed confirm two-factor authentication"
+	MESSAGE_SUCCESS_CONFIRM_TWO_FACTOR        = "success confirm two-factor authentication"
+	MESSAGE_FAILED_DISABLE_TWO_FACTOR         = "failed disable two-factor authentication"
+	MESSAGE_SUCCESS_DISABLE_TWO_FACTOR        = "success disable two-factor authentication"
+	MESSAGE_FAILED_REGENERATE_RECOVERY_CODES  = "failed regenerate recovery codes"
+	MESSAGE_SUCCESS_REGENERATE_RECOVERY_CODES = "success regenerate recovery codes"
+	MESSAGE_FAILED_LOGIN_TWO_FACTOR           = "failed login with two-factor authentication"
+	MESSAGE_SUCCESS_LOGIN_TWO_FACTOR_REQUIRED = "two-factor authentication required"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled  = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled      = errors.New("two-factor authentication not enabled")
+	ErrTwoFactorNotEnrolled     = errors.New("two-factor authentication not enrolled")
+	ErrInvalidTwoFactorCode     = errors.New("invalid two-factor code")
+	ErrChallengeTokenInvalid    = errors.New("challenge token invalid")
+	ErrTooManyTwoFactorAttempts = errors.New("too many two-factor attempts")
+)
+
+type (
+	// LoginResponse carries the tokens, or only a challenge token when the
+	// user has to complete the login with a second factor
+	LoginResponse struct {
+		*TokenResponse
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token,omitempty"`
+	}
+
+	TwoFactorEnrollResponse struct {
+		Secret     string `json:"secret"`
+		OTPAuthURI string `json:"otpauth_uri"`
+	}
+
+	TwoFactorCodeRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	TwoFactorLoginRequest struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+		Code           string `json:"code" binding:"required"`
+		DeviceLabel    string `json:"device_label" binding:"omitempty,max=100"`
+	}
+
+	RecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+)
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type LinkedIdentityRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, identity entities.LinkedIdentity) (entities.LinkedIdentity, error)
+	FindByProviderSubject(ctx context.Context, tx *gorm.DB, provider string, subject string) (entities.LinkedIdentity, error)
+	FindByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]entities.LinkedIdentity, error)
+	CountByUserID(ctx context.Context, tx *gorm.DB, userID string) (int64, error)
+}
+
+type linkedIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkedIdentityRepository(db *gorm.DB) LinkedIdentityRepository {
+	return &linkedIdentityRepository{
+		db: db,
+	}
+}
+
+func (r *linkedIdentityRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	identity entities.LinkedIdentity,
+) (entities.LinkedIdentity, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&identity).Error; err != nil {
+		return entities.LinkedIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+func (r *linkedIdentityRepository) FindByProviderSubject(
+	ctx context.Context,
+	tx *gorm.DB,
+	provider string,
+	subject string,
+) (entities.LinkedIdentity, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var identity entities.LinkedIdentity
+	err := tx.WithContext(ctx).
+		Preload("User").
+		Where("provider = ? AND subject = ?", provider, subject).
+		Take(&identity).Error
+	if err != nil {
+		return entities.LinkedIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+func (r *linkedIdentityRepository) FindByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]entities.LinkedIdentity, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var identities []entities.LinkedIdentity
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (r *linkedIdentityRepository) CountByUserID(ctx context.Context, tx *gorm.DB, userID string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).Model(&entities.LinkedIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
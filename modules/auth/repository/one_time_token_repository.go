@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, token entities.OneTimeToken) (entities.OneTimeToken, error)
+	Consume(ctx context.Context, tx *gorm.DB, tokenHash string, purpose string) (entities.OneTimeToken, error)
+	InvalidateByUserID(ctx context.Context, tx *gorm.DB, userID string, purpose string) error
+	DeleteExpired(ctx context.Context, tx *gorm.DB) error
+}
+
+type oneTimeTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewOneTimeTokenRepository(db *gorm.DB) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{
+		db: db,
+	}
+}
+
+func (r *oneTimeTokenRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	token entities.OneTimeToken,
+) (entities.OneTimeToken, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&token).Error; err != nil {
+		return entities.OneTimeToken{}, err
+	}
+
+	return token, nil
+}
+
+// Consume marks a matching, unused and unexpired token as consumed in a single
+// statement, so a token can never be redeemed twice.
+func (r *oneTimeTokenRepository) Consume(
+	ctx context.Context,
+	tx *gorm.DB,
+	tokenHash string,
+	purpose string,
+) (entities.OneTimeToken, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	now := time.Now()
+	var token entities.OneTimeToken
+	result := tx.WithContext(ctx).
+		Model(&token).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND consumed_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Update("consumed_at", now)
+	if result.Error != nil {
+		return entities.OneTimeToken{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entities.OneTimeToken{}, gorm.ErrRecordNotFound
+	}
+
+	return token, nil
+}
+
+func (r *oneTimeTokenRepository) InvalidateByUserID(ctx context.Context, tx *gorm.DB, userID string, purpose string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(&entities.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+		Update("consumed_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *oneTimeTokenRepository) DeleteExpired(ctx context.Context, tx *gorm.DB) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&entities.OneTimeToken{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, history entities.PasswordHistory) error
+	FindRecentByUserID(ctx context.Context, tx *gorm.DB, userID string, limit int) ([]entities.PasswordHistory, error)
+	Prune(ctx context.Context, tx *gorm.DB, userID string, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{
+		db: db,
+	}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, tx *gorm.DB, history entities.PasswordHistory) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).Create(&history).Error
+}
+
+// FindRecentByUserID returns the newest entries first
+func (r *passwordHistoryRepository) FindRecentByUserID(
+	ctx context.Context,
+	tx *gorm.DB,
+	userID string,
+	limit int,
+) ([]entities.PasswordHistory, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var histories []entities.PasswordHistory
+	if err := tx.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
+
+// Prune deletes everything but the newest keep entries of the user
+func (r *passwordHistoryRepository) Prune(ctx context.Context, tx *gorm.DB, userID string, keep int) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	newest := tx.Model(&entities.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(keep)
+
+	return tx.WithContext(ctx).
+		Where("user_id = ? AND id NOT IN (?)", userID, newest).
+		Delete(&entities.PasswordHistory{}).Error
+}
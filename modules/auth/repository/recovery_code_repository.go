@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, tx *gorm.DB, userID string, codes []entities.RecoveryCode) error
+	Consume(ctx context.Context, tx *gorm.DB, userID string, codeHash string) error
+	DeleteByUserID(ctx context.Context, tx *gorm.DB, userID string) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{
+		db: db,
+	}
+}
+
+// Replace drops every code of the user and stores the new set
+func (r *recoveryCodeRepository) Replace(
+	ctx context.Context,
+	tx *gorm.DB,
+	userID string,
+	codes []entities.RecoveryCode,
+) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	if len(codes) == 0 {
+		return nil
+	}
+
+	return tx.WithContext(ctx).Create(&codes).Error
+}
+
+// Consume marks an unused code as used in a single statement, so a code can
+// never be redeemed twice
+func (r *recoveryCodeRepository) Consume(ctx context.Context, tx *gorm.DB, userID string, codeHash string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *recoveryCodeRepository) DeleteByUserID(ctx context.Context, tx *gorm.DB, userID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.RecoveryCode{}).Error
+}
@@ -6,13 +6,20 @@ import (
 
 	"blog/database/entities"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, tx *gorm.DB, token entities.RefreshToken) (entities.RefreshToken, error)
-	FindByToken(ctx context.Context, tx *gorm.DB, token string) (entities.RefreshToken, error)
+	FindByToken(ctx context.Context, tx *gorm.DB, tokenHash string) (entities.RefreshToken, error)
 	DeleteByUserID(ctx context.Context, tx *gorm.DB, userID string) error
-	DeleteByToken(ctx context.Context, tx *gorm.DB, token string) error
+	DeleteByToken(ctx context.Context, tx *gorm.DB, tokenHash string) error
+	MarkAsUsed(ctx context.Context, tx *gorm.DB, id string) error
+	RevokeFamily(ctx context.Context, tx *gorm.DB, familyID string) error
+	FindActiveByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]entities.RefreshToken, error)
+	CountActiveByUserID(ctx context.Context, tx *gorm.DB, userID string) (int64, error)
+	RevokeSession(ctx context.Context, tx *gorm.DB, userID string, familyID string) error
+	RevokeOtherSessions(ctx context.Context, tx *gorm.DB, userID string, keepFamilyID string) error
 	DeleteExpired(ctx context.Context, tx *gorm.DB) error
 }
 
@@ -42,7 +49,7 @@ func (r *refreshTokenRepository) Create(
 	return token, nil
 }
 
-func (r *refreshTokenRepository) FindByToken(ctx context.Context, tx *gorm.DB, token string) (
+func (r *refreshTokenRepository) FindByToken(ctx context.Context, tx *gorm.DB, tokenHash string) (
 	entities.RefreshToken,
 	error,
 ) {
@@ -50,8 +57,13 @@ func (r *refreshTokenRepository) FindByToken(ctx context.Context, tx *gorm.DB, t
 		tx = r.db
 	}
 
+	// The row is locked so concurrent rotations of the same token are serialized
 	var refreshToken entities.RefreshToken
-	if err := tx.WithContext(ctx).Where("token = ?", token).Preload("User").Take(&refreshToken).Error; err != nil {
+	if err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		Preload("User").
+		Take(&refreshToken).Error; err != nil {
 		return entities.RefreshToken{}, err
 	}
 
@@ -70,12 +82,12 @@ func (r *refreshTokenRepository) DeleteByUserID(ctx context.Context, tx *gorm.DB
 	return nil
 }
 
-func (r *refreshTokenRepository) DeleteByToken(ctx context.Context, tx *gorm.DB, token string) error {
+func (r *refreshTokenRepository) DeleteByToken(ctx context.Context, tx *gorm.DB, tokenHash string) error {
 	if tx == nil {
 		tx = r.db
 	}
 
-	if err := tx.WithContext(ctx).Where("token = ?", token).Delete(&entities.RefreshToken{}).Error; err != nil {
+	if err := tx.WithContext(ctx).Where("token_hash = ?", tokenHash).Delete(&entities.RefreshToken{}).Error; err != nil {
 		return err
 	}
 
@@ -92,4 +104,111 @@ func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, tx *gorm.DB)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *refreshTokenRepository) MarkAsUsed(ctx context.Context, tx *gorm.DB, id string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("id = ?", id).
+		Update("used_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, tx *gorm.DB, familyID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindActiveByUserID returns the current token of every live session of a user
+func (r *refreshTokenRepository) FindActiveByUserID(ctx context.Context, tx *gorm.DB, userID string) (
+	[]entities.RefreshToken,
+	error,
+) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var refreshTokens []entities.RefreshToken
+	if err := tx.WithContext(ctx).
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at desc").
+		Find(&refreshTokens).Error; err != nil {
+		return nil, err
+	}
+
+	return refreshTokens, nil
+}
+
+// CountActiveByUserID counts the sessions FindActiveByUserID returns
+func (r *refreshTokenRepository) CountActiveByUserID(ctx context.Context, tx *gorm.DB, userID string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *refreshTokenRepository) RevokeSession(ctx context.Context, tx *gorm.DB, userID string, familyID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *refreshTokenRepository) RevokeOtherSessions(
+	ctx context.Context,
+	tx *gorm.DB,
+	userID string,
+	keepFamilyID string,
+) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).
+		Model(&entities.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userID, keepFamilyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
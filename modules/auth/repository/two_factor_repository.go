@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, tx *gorm.DB, userID string) (entities.TwoFactorCredential, error)
+	Save(ctx context.Context, tx *gorm.DB, credential entities.TwoFactorCredential) (entities.TwoFactorCredential, error)
+	UseStep(ctx context.Context, tx *gorm.DB, id string, step int64) error
+	DeleteByUserID(ctx context.Context, tx *gorm.DB, userID string) error
+}
+
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{
+		db: db,
+	}
+}
+
+func (r *twoFactorRepository) FindByUserID(
+	ctx context.Context,
+	tx *gorm.DB,
+	userID string,
+) (entities.TwoFactorCredential, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var credential entities.TwoFactorCredential
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Take(&credential).Error; err != nil {
+		return entities.TwoFactorCredential{}, err
+	}
+
+	return credential, nil
+}
+
+func (r *twoFactorRepository) Save(
+	ctx context.Context,
+	tx *gorm.DB,
+	credential entities.TwoFactorCredential,
+) (entities.TwoFactorCredential, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Save(&credential).Error; err != nil {
+		return entities.TwoFactorCredential{}, err
+	}
+
+	return credential, nil
+}
+
+// UseStep records the time step of an accepted code. It only succeeds for a
+// step later than the last one used, so a code cannot be replayed.
+func (r *twoFactorRepository) UseStep(ctx context.Context, tx *gorm.DB, id string, step int64) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.TwoFactorCredential{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *twoFactorRepository) DeleteByUserID(ctx context.Context, tx *gorm.DB, userID string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.TwoFactorCredential{}).Error
+}
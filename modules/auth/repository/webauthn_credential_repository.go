@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, credential entities.WebAuthnCredential) (entities.WebAuthnCredential, error)
+	FindByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]entities.WebAuthnCredential, error)
+	CountByUserID(ctx context.Context, tx *gorm.DB, userID string) (int64, error)
+	UpdateAfterLogin(ctx context.Context, tx *gorm.DB, credentialID []byte, signCount int64, backupState bool) error
+	Delete(ctx context.Context, tx *gorm.DB, userID string, id string) error
+}
+
+type webAuthnCredentialRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnCredentialRepository(db *gorm.DB) WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{
+		db: db,
+	}
+}
+
+func (r *webAuthnCredentialRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	credential entities.WebAuthnCredential,
+) (entities.WebAuthnCredential, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&credential).Error; err != nil {
+		return entities.WebAuthnCredential{}, err
+	}
+
+	return credential, nil
+}
+
+func (r *webAuthnCredentialRepository) FindByUserID(
+	ctx context.Context,
+	tx *gorm.DB,
+	userID string,
+) ([]entities.WebAuthnCredential, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var credentials []entities.WebAuthnCredential
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (r *webAuthnCredentialRepository) CountByUserID(ctx context.Context, tx *gorm.DB, userID string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).Model(&entities.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UpdateAfterLogin stores the counter and backup state reported by the
+// authenticator on a successful assertion
+func (r *webAuthnCredentialRepository) UpdateAfterLogin(
+	ctx context.Context,
+	tx *gorm.DB,
+	credentialID []byte,
+	signCount int64,
+	backupState bool,
+) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entities.WebAuthnCredential{}).
+		Where("credential_id = ?", credentialID).
+		Updates(map[string]any{
+			"sign_count":   signCount,
+			"backup_state": backupState,
+			"last_used_at": time.Now(),
+		}).Error
+}
+
+func (r *webAuthnCredentialRepository) Delete(ctx context.Context, tx *gorm.DB, userID string, id string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&entities.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -1,14 +1,73 @@
 package auth
 
 import (
+	"blog/middlewares"
+	"blog/modules/auth/controller"
+	"blog/modules/auth/service"
+	rbacService "blog/modules/rbac/service"
+	"blog/pkg/constants"
 	"github.com/gin-gonic/gin"
 	"github.com/samber/do"
 )
 
 func RegisterRoutes(router *gin.Engine, injector *do.Injector) {
+	authController := do.MustInvoke[controller.AuthController](injector)
+	sessionController := do.MustInvoke[controller.SessionController](injector)
+	twoFactorController := do.MustInvoke[controller.TwoFactorController](injector)
+	oauthController := do.MustInvoke[controller.OAuthController](injector)
+	passkeyController := do.MustInvoke[controller.PasskeyController](injector)
+	jwtService := do.MustInvokeNamed[service.JWTService](injector, constants.JWTService)
+	revocationService := do.MustInvokeNamed[service.TokenRevocationService](injector, constants.TokenRevocationService)
+	rbacService := do.MustInvokeNamed[rbacService.RBACService](injector, constants.RBACService)
+
+	router.GET("/.well-known/jwks.json", authController.JWKS)
+
 	authRoute := router.Group("/api/v1/auth")
 	{
-		_ = authRoute
+		authRoute.POST("/register", authController.Register)
+		authRoute.POST("/login", authController.Login)
+		authRoute.POST("/login/2fa", authController.LoginTwoFactor)
+		authRoute.GET("/oauth/:provider", oauthController.Authorize)
+		authRoute.GET("/oauth/:provider/callback", oauthController.Callback)
+		authRoute.POST("/refresh", authController.RefreshToken)
+		authRoute.POST("/logout", middlewares.Authenticate(jwtService, revocationService), authController.Logout)
+		authRoute.POST("/send-verification-email", authController.SendVerificationEmail)
+		authRoute.POST("/verify-email", authController.VerifyEmail)
+		authRoute.POST("/magic-link", authController.SendMagicLink)
+		authRoute.POST("/magic-link/verify", authController.LoginMagicLink)
+		authRoute.POST("/send-password-reset", authController.SendPasswordReset)
+		authRoute.POST("/reset-password", authController.ResetPassword)
+		authRoute.POST("/change-password", middlewares.Authenticate(jwtService, revocationService), authController.ChangePassword)
+
+		sessionRoutes := authRoute.Group("/sessions", middlewares.Authenticate(jwtService, revocationService))
+		{
+			sessionRoutes.GET("", sessionController.ListSessions)
+			sessionRoutes.DELETE("/:id", sessionController.RevokeSession)
+			sessionRoutes.POST("/revoke-others", sessionController.RevokeOtherSessions)
+		}
+
+		authRoute.POST("/passkeys/login/begin", passkeyController.BeginLogin)
+		authRoute.POST("/passkeys/login/finish", passkeyController.FinishLogin)
+
+		passkeyRoutes := authRoute.Group("/passkeys", middlewares.Authenticate(jwtService, revocationService))
+		{
+			passkeyRoutes.GET("", passkeyController.ListPasskeys)
+			passkeyRoutes.POST("/register/begin", passkeyController.BeginRegistration)
+			passkeyRoutes.POST("/register/finish", passkeyController.FinishRegistration)
+			passkeyRoutes.DELETE("/:id", passkeyController.DeletePasskey)
+		}
+
+		twoFactorRoutes := authRoute.Group("/2fa", middlewares.Authenticate(jwtService, revocationService))
+		{
+			twoFactorRoutes.POST("/enroll", twoFactorController.Enroll)
+			twoFactorRoutes.POST("/confirm", twoFactorController.Confirm)
+			twoFactorRoutes.POST("/disable", twoFactorController.Disable)
+			twoFactorRoutes.POST("/recovery-codes", twoFactorController.RegenerateRecoveryCodes)
+		}
 	}
 
+	adminRoutes := router.Group("/api/v1/admin/users", middlewares.Authenticate(jwtService, revocationService))
+	{
+		adminRoutes.POST("/:id/unlock", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_USER_UPDATE), authController.UnlockAccount)
+	}
 }
@@ -2,12 +2,20 @@ package service
 
 import (
 	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
 
+	"blog/config"
 	"blog/database/entities"
 	"blog/modules/auth/dto"
 	authRepo "blog/modules/auth/repository"
 	userDto "blog/modules/user/dto"
 	"blog/modules/user/repository"
+	"blog/pkg/cache"
+	"blog/pkg/constants"
 	"blog/pkg/helpers"
 	"blog/pkg/utils"
 	"github.com/google/uuid"
@@ -16,32 +24,68 @@ import (
 
 type AuthService interface {
 	Register(ctx context.Context, req userDto.UserCreateRequest) (userDto.UserResponse, error)
-	Login(ctx context.Context, req userDto.UserLoginRequest) (dto.TokenResponse, error)
-	RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (dto.TokenResponse, error)
-	Logout(ctx context.Context, userId string) error
+	Login(ctx context.Context, req userDto.UserLoginRequest, session dto.SessionInfo) (dto.LoginResponse, error)
+	LoginTwoFactor(ctx context.Context, req dto.TwoFactorLoginRequest, session dto.SessionInfo) (dto.TokenResponse, error)
+	RefreshToken(ctx context.Context, req dto.RefreshTokenRequest, session dto.SessionInfo) (dto.TokenResponse, error)
+	Logout(ctx context.Context, userId string, accessToken string) error
 	SendVerificationEmail(ctx context.Context, req userDto.SendVerificationEmailRequest) error
 	VerifyEmail(ctx context.Context, req userDto.VerifyEmailRequest) (userDto.VerifyEmailResponse, error)
 	SendPasswordReset(ctx context.Context, req dto.SendPasswordResetRequest) error
 	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
+	ChangePassword(ctx context.Context, userId string, sessionId string, req dto.ChangePasswordRequest, session dto.SessionInfo) error
+	SendMagicLink(ctx context.Context, req dto.SendMagicLinkRequest, session dto.SessionInfo) error
+	LoginMagicLink(ctx context.Context, req dto.MagicLinkLoginRequest, session dto.SessionInfo) (dto.LoginResponse, error)
+	UnlockAccount(ctx context.Context, userId string) error
 }
 
+const (
+	emailVerificationTokenExpiry = time.Hour * 24
+	passwordResetTokenExpiry     = time.Hour
+
+	magicLinkEmailKeyPrefix = "magic-link:email:"
+	magicLinkIPKeyPrefix    = "magic-link:ip:"
+)
+
 type authService struct {
 	userRepository         repository.UserRepository
 	refreshTokenRepository authRepo.RefreshTokenRepository
+	oneTimeTokenRepository authRepo.OneTimeTokenRepository
 	jwtService             JWTService
+	revocationService      TokenRevocationService
+	twoFactorService       TwoFactorService
+	loginThrottleService   LoginThrottleService
+	passwordPolicy         PasswordPolicyService
+	magicLinkConfig        *config.MagicLinkConfig
+	limiter                *cache.Limiter
+	tokenIssuer            *tokenIssuer
 	db                     *gorm.DB
 }
 
 func NewAuthService(
 	userRepo repository.UserRepository,
 	refreshTokenRepo authRepo.RefreshTokenRepository,
+	oneTimeTokenRepo authRepo.OneTimeTokenRepository,
 	jwtService JWTService,
+	revocationService TokenRevocationService,
+	twoFactorService TwoFactorService,
+	loginThrottleService LoginThrottleService,
+	passwordPolicy PasswordPolicyService,
+	magicLinkConfig *config.MagicLinkConfig,
+	store cache.Store,
 	db *gorm.DB,
 ) AuthService {
 	return &authService{
 		userRepository:         userRepo,
 		refreshTokenRepository: refreshTokenRepo,
+		oneTimeTokenRepository: oneTimeTokenRepo,
 		jwtService:             jwtService,
+		revocationService:      revocationService,
+		twoFactorService:       twoFactorService,
+		loginThrottleService:   loginThrottleService,
+		passwordPolicy:         passwordPolicy,
+		magicLinkConfig:        magicLinkConfig,
+		limiter:                cache.NewLimiter(store),
+		tokenIssuer:            newTokenIssuer(refreshTokenRepo, jwtService, twoFactorService, db),
 		db:                     db,
 	}
 }
@@ -56,22 +100,32 @@ func (s *authService) Register(ctx context.Context, req userDto.UserCreateReques
 		return userDto.UserResponse{}, userDto.ErrEmailAlreadyExists
 	}
 
-	hashedPassword, err := helpers.HashPassword(req.Password)
-	if err != nil {
-		return userDto.UserResponse{}, err
-	}
-
 	user := entities.User{
 		ID:         uuid.New(),
 		Name:       req.Name,
 		Email:      req.Email,
 		TelpNumber: req.TelpNumber,
-		Password:   string(hashedPassword),
+		Password:   req.Password,
 		Role:       "user",
 		IsVerified: false,
 	}
 
-	createdUser, err := s.userRepository.Register(ctx, s.db, user)
+	// Not an existing user yet, so only the rules that need no history apply
+	if err := s.passwordPolicy.Validate(ctx, s.db, entities.User{Name: req.Name, Email: req.Email}, req.Password); err != nil {
+		return userDto.UserResponse{}, err
+	}
+
+	var createdUser entities.User
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Password is hashed by the User.BeforeCreate hook
+		registered, err := s.userRepository.Register(ctx, tx, user)
+		if err != nil {
+			return err
+		}
+
+		createdUser = registered
+		return s.passwordPolicy.Remember(ctx, tx, createdUser.ID.String(), createdUser.Password)
+	})
 	if err != nil {
 		return userDto.UserResponse{}, err
 	}
@@ -86,74 +140,170 @@ func (s *authService) Register(ctx context.Context, req userDto.UserCreateReques
 	}, nil
 }
 
-func (s *authService) Login(ctx context.Context, req userDto.UserLoginRequest) (dto.TokenResponse, error) {
+func (s *authService) Login(
+	ctx context.Context,
+	req userDto.UserLoginRequest,
+	session dto.SessionInfo,
+) (dto.LoginResponse, error) {
+	if err := s.loginThrottleService.Attempt(ctx, req.Email, session.IPAddress); err != nil {
+		return dto.LoginResponse{}, err
+	}
+
 	user, err := s.userRepository.GetUserByEmail(ctx, s.db, req.Email)
 	if err != nil {
-		return dto.TokenResponse{}, userDto.ErrEmailNotFound
+		return dto.LoginResponse{}, s.loginFailed(ctx, req.Email, session, userDto.ErrEmailNotFound)
 	}
 
-	isValid, err := helpers.CheckPassword(user.Password, []byte(req.Password))
+	isValid, needsUpgrade, err := helpers.CheckPassword(user.Password, []byte(req.Password))
 	if err != nil || !isValid {
-		return dto.TokenResponse{}, dto.ErrInvalidCredentials
+		return dto.LoginResponse{}, s.loginFailed(ctx, req.Email, session, dto.ErrInvalidCredentials)
 	}
 
-	accessToken := s.jwtService.GenerateAccessToken(user.ID.String(), user.Role)
-	refreshTokenString, expiresAt := s.jwtService.GenerateRefreshToken()
-
-	refreshToken := entities.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		Token:     refreshTokenString,
-		ExpiresAt: expiresAt,
+	if needsUpgrade {
+		s.rehashPassword(ctx, user, req.Password)
 	}
 
-	_, err = s.refreshTokenRepository.Create(ctx, s.db, refreshToken)
+	response, err := s.tokenIssuer.login(ctx, user, session)
 	if err != nil {
-		return dto.TokenResponse{}, err
+		return dto.LoginResponse{}, err
 	}
 
-	return dto.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenString,
-		Role:         user.Role,
-	}, nil
+	// The password alone does not complete a login with two-factor
+	// authentication on; LoginTwoFactor clears the failures instead
+	if !response.TwoFactorRequired {
+		if err := s.loginThrottleService.RecordSuccess(ctx, req.Email, session.IPAddress); err != nil {
+			return dto.LoginResponse{}, err
+		}
+	}
+
+	return response, nil
 }
 
-func (s *authService) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (dto.TokenResponse, error) {
-	refreshToken, err := s.refreshTokenRepository.FindByToken(ctx, s.db, req.RefreshToken)
+// rehashPassword replaces a hash made with another algorithm or weaker
+// parameters than the configured ones, while the plain password is at hand.
+// A failure only postpones the upgrade to the next login.
+func (s *authService) rehashPassword(ctx context.Context, user entities.User, password string) {
+	hash, err := helpers.HashPassword(password)
 	if err != nil {
-		return dto.TokenResponse{}, dto.ErrRefreshTokenNotFound
+		return
 	}
 
-	accessToken := s.jwtService.GenerateAccessToken(refreshToken.UserID.String(), refreshToken.User.Role)
-	newRefreshTokenString, expiresAt := s.jwtService.GenerateRefreshToken()
+	_ = s.userRepository.UpdateColumns(ctx, s.db, user.ID.String(), map[string]any{"password": hash})
+}
+
+// loginFailed records that an attempt failed and returns the error to report
+func (s *authService) loginFailed(ctx context.Context, email string, session dto.SessionInfo, reason error) error {
+	if err := s.loginThrottleService.RecordFailure(ctx, email, session.IPAddress); err != nil {
+		return err
+	}
+	return reason
+}
 
-	err = s.refreshTokenRepository.DeleteByToken(ctx, s.db, req.RefreshToken)
+// LoginTwoFactor completes a login started by Login with the challenge token
+// and a TOTP or recovery code
+func (s *authService) LoginTwoFactor(
+	ctx context.Context,
+	req dto.TwoFactorLoginRequest,
+	session dto.SessionInfo,
+) (dto.TokenResponse, error) {
+	userId, err := s.twoFactorService.VerifyChallenge(ctx, req.ChallengeToken, req.Code)
 	if err != nil {
 		return dto.TokenResponse{}, err
 	}
 
-	newRefreshToken := entities.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    refreshToken.UserID,
-		Token:     newRefreshTokenString,
-		ExpiresAt: expiresAt,
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.TokenResponse{}, userDto.ErrUserNotFound
+	}
+
+	if err := s.loginThrottleService.RecordSuccess(ctx, user.Email, session.IPAddress); err != nil {
+		return dto.TokenResponse{}, err
 	}
 
-	_, err = s.refreshTokenRepository.Create(ctx, s.db, newRefreshToken)
+	return s.tokenIssuer.issueTokens(ctx, s.db, user, session)
+}
+
+func (s *authService) RefreshToken(
+	ctx context.Context,
+	req dto.RefreshTokenRequest,
+	session dto.SessionInfo,
+) (dto.TokenResponse, error) {
+	var (
+		response      dto.TokenResponse
+		reuseDetected bool
+	)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		refreshToken, err := s.refreshTokenRepository.FindByToken(ctx, tx, s.jwtService.HashRefreshToken(req.RefreshToken))
+		if err != nil {
+			return dto.ErrRefreshTokenNotFound
+		}
+
+		if refreshToken.RevokedAt != nil {
+			return dto.ErrRefreshTokenRevoked
+		}
+
+		// A rotated token presented again has leaked: revoke the whole family.
+		// The revocation must be committed, so the error is returned after the transaction.
+		if refreshToken.UsedAt != nil {
+			reuseDetected = true
+			return s.refreshTokenRepository.RevokeFamily(ctx, tx, refreshToken.FamilyID.String())
+		}
+
+		if time.Now().After(refreshToken.ExpiresAt) {
+			return dto.ErrRefreshTokenExpired
+		}
+
+		if err := s.refreshTokenRepository.MarkAsUsed(ctx, tx, refreshToken.ID.String()); err != nil {
+			return err
+		}
+
+		newRefreshTokenString, expiresAt := s.jwtService.GenerateRefreshToken()
+		newRefreshToken := entities.RefreshToken{
+			ID:               uuid.New(),
+			UserID:           refreshToken.UserID,
+			FamilyID:         refreshToken.FamilyID,
+			TokenHash:        s.jwtService.HashRefreshToken(newRefreshTokenString),
+			ExpiresAt:        expiresAt,
+			UserAgent:        session.UserAgent,
+			IPAddress:        session.IPAddress,
+			DeviceLabel:      refreshToken.DeviceLabel,
+			SessionStartedAt: refreshToken.SessionStartedAt,
+			LastUsedAt:       time.Now(),
+		}
+
+		if _, err := s.refreshTokenRepository.Create(ctx, tx, newRefreshToken); err != nil {
+			return err
+		}
+
+		response = dto.TokenResponse{
+			AccessToken: s.jwtService.GenerateAccessToken(
+				refreshToken.UserID.String(),
+				refreshToken.User.Role,
+				refreshToken.FamilyID.String(),
+			),
+			RefreshToken: newRefreshTokenString,
+			Role:         refreshToken.User.Role,
+		}
+		return nil
+	})
 	if err != nil {
 		return dto.TokenResponse{}, err
 	}
 
-	return dto.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshTokenString,
-		Role:         refreshToken.User.Role,
-	}, nil
+	if reuseDetected {
+		return dto.TokenResponse{}, dto.ErrRefreshTokenReused
+	}
+
+	return response, nil
 }
 
-func (s *authService) Logout(ctx context.Context, userId string) error {
-	return s.refreshTokenRepository.DeleteByUserID(ctx, s.db, userId)
+func (s *authService) Logout(ctx context.Context, userId string, accessToken string) error {
+	if err := s.refreshTokenRepository.DeleteByUserID(ctx, s.db, userId); err != nil {
+		return err
+	}
+
+	return s.revocationService.RevokeToken(ctx, accessToken)
 }
 
 func (s *authService) SendVerificationEmail(ctx context.Context, req userDto.SendVerificationEmailRequest) error {
@@ -166,7 +316,15 @@ func (s *authService) SendVerificationEmail(ctx context.Context, req userDto.Sen
 		return userDto.ErrAccountAlreadyVerified
 	}
 
-	verificationToken := s.jwtService.GenerateAccessToken(user.ID.String(), "verification")
+	verificationToken, err := s.issueOneTimeToken(
+		ctx,
+		user,
+		constants.ENUM_TOKEN_PURPOSE_EMAIL_VERIFICATION,
+		emailVerificationTokenExpiry,
+	)
+	if err != nil {
+		return err
+	}
 
 	subject := "Email Verification"
 	body := "Please verify your email using this token: " + verificationToken
@@ -175,30 +333,38 @@ func (s *authService) SendVerificationEmail(ctx context.Context, req userDto.Sen
 }
 
 func (s *authService) VerifyEmail(ctx context.Context, req userDto.VerifyEmailRequest) (userDto.VerifyEmailResponse, error) {
-	token, err := s.jwtService.ValidateToken(req.Token)
-	if err != nil || !token.Valid {
-		return userDto.VerifyEmailResponse{}, userDto.ErrTokenInvalid
-	}
-
-	userId, err := s.jwtService.GetUserIDByToken(req.Token)
-	if err != nil {
-		return userDto.VerifyEmailResponse{}, userDto.ErrTokenInvalid
-	}
-
-	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
-	if err != nil {
-		return userDto.VerifyEmailResponse{}, userDto.ErrUserNotFound
-	}
-
-	user.IsVerified = true
-	updatedUser, err := s.userRepository.Update(ctx, s.db, user)
+	var user entities.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := s.oneTimeTokenRepository.Consume(
+			ctx,
+			tx,
+			helpers.HashToken(req.Token),
+			constants.ENUM_TOKEN_PURPOSE_EMAIL_VERIFICATION,
+		)
+		if err != nil {
+			return userDto.ErrTokenInvalid
+		}
+
+		user, err = s.userRepository.GetUserById(ctx, tx, token.UserID.String())
+		if err != nil {
+			return userDto.ErrUserNotFound
+		}
+
+		// Only the changed column is written, so the rest of a row changed meanwhile is kept
+		if _, err := s.userRepository.Update(ctx, tx, entities.User{ID: user.ID, IsVerified: true}); err != nil {
+			return err
+		}
+
+		user.IsVerified = true
+		return nil
+	})
 	if err != nil {
 		return userDto.VerifyEmailResponse{}, err
 	}
 
 	return userDto.VerifyEmailResponse{
-		Email:      updatedUser.Email,
-		IsVerified: updatedUser.IsVerified,
+		Email:      user.Email,
+		IsVerified: user.IsVerified,
 	}, nil
 }
 
@@ -208,7 +374,15 @@ func (s *authService) SendPasswordReset(ctx context.Context, req dto.SendPasswor
 		return userDto.ErrEmailNotFound
 	}
 
-	resetToken := s.jwtService.GenerateAccessToken(user.ID.String(), "password_reset")
+	resetToken, err := s.issueOneTimeToken(
+		ctx,
+		user,
+		constants.ENUM_TOKEN_PURPOSE_PASSWORD_RESET,
+		passwordResetTokenExpiry,
+	)
+	if err != nil {
+		return err
+	}
 
 	subject := "Password Reset"
 	body := "Please reset your password using this token: " + resetToken
@@ -217,31 +391,277 @@ func (s *authService) SendPasswordReset(ctx context.Context, req dto.SendPasswor
 }
 
 func (s *authService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
-	token, err := s.jwtService.ValidateToken(req.Token)
-	if err != nil || !token.Valid {
-		return dto.ErrPasswordResetToken
-	}
-
-	userId, err := s.jwtService.GetUserIDByToken(req.Token)
+	var userId string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := s.oneTimeTokenRepository.Consume(
+			ctx,
+			tx,
+			helpers.HashToken(req.Token),
+			constants.ENUM_TOKEN_PURPOSE_PASSWORD_RESET,
+		)
+		if err != nil {
+			return dto.ErrPasswordResetToken
+		}
+
+		user, err := s.userRepository.GetUserById(ctx, tx, token.UserID.String())
+		if err != nil {
+			return userDto.ErrUserNotFound
+		}
+
+		if err := s.passwordPolicy.Validate(ctx, tx, user, req.NewPassword); err != nil {
+			return err
+		}
+
+		userId = user.ID.String()
+		if err := s.setPassword(ctx, tx, userId, req.NewPassword); err != nil {
+			return err
+		}
+
+		return s.refreshTokenRepository.DeleteByUserID(ctx, tx, userId)
+	})
 	if err != nil {
-		return dto.ErrPasswordResetToken
+		return err
 	}
 
+	return s.revocationService.RevokeUserTokens(ctx, userId)
+}
+
+// ChangePassword sets a new password for a user who knows the current one.
+// Every session but sessionId is revoked, as are all access tokens, so the
+// current client keeps going by refreshing once.
+func (s *authService) ChangePassword(
+	ctx context.Context,
+	userId string,
+	sessionId string,
+	req dto.ChangePasswordRequest,
+	session dto.SessionInfo,
+) error {
 	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
 	if err != nil {
 		return userDto.ErrUserNotFound
 	}
 
-	hashedPassword, err := helpers.HashPassword(req.NewPassword)
+	// Guessing the current password is throttled like a login
+	if err := s.loginThrottleService.Attempt(ctx, user.Email, session.IPAddress); err != nil {
+		return err
+	}
+
+	if isValid, _, _ := helpers.CheckPassword(user.Password, []byte(req.CurrentPassword)); !isValid {
+		return s.loginFailed(ctx, user.Email, session, dto.ErrCurrentPassword)
+	}
+
+	if err := s.loginThrottleService.RecordSuccess(ctx, user.Email, session.IPAddress); err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.passwordPolicy.Validate(ctx, tx, user, req.NewPassword); err != nil {
+			return err
+		}
+
+		if err := s.setPassword(ctx, tx, userId, req.NewPassword); err != nil {
+			return err
+		}
+
+		if sessionId == "" {
+			return s.refreshTokenRepository.DeleteByUserID(ctx, tx, userId)
+		}
+		return s.refreshTokenRepository.RevokeOtherSessions(ctx, tx, userId, sessionId)
+	})
 	if err != nil {
 		return err
 	}
 
-	user.Password = string(hashedPassword)
-	_, err = s.userRepository.Update(ctx, s.db, user)
+	if err := s.revocationService.RevokeUserTokens(ctx, userId); err != nil {
+		return err
+	}
+
+	// The password is changed at this point, a failed mail must not report otherwise
+	_ = s.sendPasswordChangedEmail(user, session)
+
+	return nil
+}
+
+func (s *authService) sendPasswordChangedEmail(user entities.User, session dto.SessionInfo) error {
+	body, err := utils.RenderMailTemplate("password_changed", map[string]string{
+		"Name":      user.Name,
+		"Time":      time.Now().UTC().Format(time.RFC1123),
+		"IPAddress": session.IPAddress,
+		"Device":    session.DeviceLabel,
+	})
+	if err != nil {
+		return err
+	}
+
+	return utils.SendMail(user.Email, "Your Password Was Changed", body)
+}
+
+// setPassword hashes and stores a password that already passed the policy
+// and adds it to the user's history
+func (s *authService) setPassword(ctx context.Context, tx *gorm.DB, userId string, password string) error {
+	hash, err := helpers.HashPassword(password)
 	if err != nil {
 		return err
 	}
 
+	if err := s.userRepository.UpdateColumns(ctx, tx, userId, map[string]any{"password": hash}); err != nil {
+		return err
+	}
+
+	return s.passwordPolicy.Remember(ctx, tx, userId, hash)
+}
+
+// SendMagicLink emails a single-use link that logs the user in without a
+// password. Requesting a new link invalidates the previous one. The answer is
+// the same whether or not the address has an account, and the link is sent in
+// the background so the response time does not tell either.
+func (s *authService) SendMagicLink(ctx context.Context, req dto.SendMagicLinkRequest, session dto.SessionInfo) error {
+	if session.IPAddress != "" {
+		retryAfter, err := s.limiter.Allow(ctx, magicLinkIPKeyPrefix+session.IPAddress, s.magicLinkConfig.MaxPerIP, s.magicLinkConfig.RateWindow)
+		if err != nil {
+			return err
+		}
+		if retryAfter > 0 {
+			return &dto.RetryAfterError{Err: dto.ErrTooManyMagicLinkRequests, RetryAfter: retryAfter}
+		}
+	}
+
+	// Refusing here would tell whether the address was asked for before, so
+	// links past the limit are silently not sent
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	retryAfter, err := s.limiter.Allow(ctx, magicLinkEmailKeyPrefix+email, s.magicLinkConfig.MaxPerEmail, s.magicLinkConfig.RateWindow)
+	if err != nil || retryAfter > 0 {
+		return err
+	}
+
+	go func() {
+		if err := s.sendMagicLink(context.WithoutCancel(ctx), req.Email); err != nil {
+			log.Println(err)
+		}
+	}()
+
 	return nil
-}
\ No newline at end of file
+}
+
+func (s *authService) sendMagicLink(ctx context.Context, email string) error {
+	user, err := s.userRepository.GetUserByEmail(ctx, s.db, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	loginToken, err := s.issueOneTimeToken(
+		ctx,
+		user,
+		constants.ENUM_TOKEN_PURPOSE_MAGIC_LINK,
+		s.magicLinkConfig.Expiry,
+	)
+	if err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.magicLinkConfig.URL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", loginToken)
+	link.RawQuery = query.Encode()
+
+	body, err := utils.RenderMailTemplate("magic_link", map[string]string{
+		"Email":  user.Email,
+		"Link":   link.String(),
+		"Expiry": s.magicLinkConfig.Expiry.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return utils.SendMail(user.Email, "Your Login Link", body)
+}
+
+// LoginMagicLink redeems a link sent by SendMagicLink. Receiving the link
+// proves the user owns the address, so the email is marked verified. Two-factor
+// authentication still applies.
+func (s *authService) LoginMagicLink(
+	ctx context.Context,
+	req dto.MagicLinkLoginRequest,
+	session dto.SessionInfo,
+) (dto.LoginResponse, error) {
+	var user entities.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := s.oneTimeTokenRepository.Consume(
+			ctx,
+			tx,
+			helpers.HashToken(req.Token),
+			constants.ENUM_TOKEN_PURPOSE_MAGIC_LINK,
+		)
+		if err != nil {
+			return dto.ErrMagicLinkInvalid
+		}
+
+		user, err = s.userRepository.GetUserById(ctx, tx, token.UserID.String())
+		if err != nil {
+			return userDto.ErrUserNotFound
+		}
+
+		if user.IsVerified {
+			return nil
+		}
+
+		user.IsVerified = true
+		return s.userRepository.UpdateColumns(ctx, tx, user.ID.String(), map[string]any{"is_verified": true})
+	})
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	return s.tokenIssuer.login(ctx, user, session)
+}
+
+// UnlockAccount lifts a login lockout before it expires
+func (s *authService) UnlockAccount(ctx context.Context, userId string) error {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return userDto.ErrUserNotFound
+	}
+
+	return s.loginThrottleService.Unlock(ctx, user.Email)
+}
+
+// issueOneTimeToken revokes any outstanding token of the same purpose for the
+// user and stores the hash of a freshly generated one. The raw token is returned
+// so it can be delivered to the user.
+func (s *authService) issueOneTimeToken(
+	ctx context.Context,
+	user entities.User,
+	purpose string,
+	expiry time.Duration,
+) (string, error) {
+	rawToken, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.oneTimeTokenRepository.InvalidateByUserID(ctx, tx, user.ID.String(), purpose); err != nil {
+			return err
+		}
+
+		_, err := s.oneTimeTokenRepository.Create(ctx, tx, entities.OneTimeToken{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			Purpose:   purpose,
+			TokenHash: helpers.HashToken(rawToken),
+			ExpiresAt: time.Now().Add(expiry),
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
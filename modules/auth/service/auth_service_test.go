@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/modules/auth/dto"
+	"blog/pkg/cache"
+)
+
+func TestSendMagicLinkHidesAccountsAndLimitsRequests(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.MagicLinkConfig{MaxPerEmail: 2, MaxPerIP: 3, RateWindow: time.Hour}
+	service := NewAuthService(
+		newFakeUserRepository(),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		cfg,
+		cache.NewMemoryStore(),
+		newTestDB(t),
+	)
+	session := dto.SessionInfo{IPAddress: "192.0.2.1"}
+
+	// Unknown addresses and addresses past their limit look like any other
+	for range cfg.MaxPerIP {
+		if err := service.SendMagicLink(ctx, dto.SendMagicLinkRequest{Email: "nobody@example.com"}, session); err != nil {
+			t.Fatalf("SendMagicLink: %v", err)
+		}
+	}
+
+	err := service.SendMagicLink(ctx, dto.SendMagicLinkRequest{Email: "other@example.com"}, session)
+	var retryErr *dto.RetryAfterError
+	if !errors.As(err, &retryErr) || !errors.Is(err, dto.ErrTooManyMagicLinkRequests) || retryErr.RetryAfter <= 0 {
+		t.Fatalf("request past the IP limit: %v", err)
+	}
+
+	other := dto.SessionInfo{IPAddress: "192.0.2.2"}
+	if err := service.SendMagicLink(ctx, dto.SendMagicLinkRequest{Email: "other@example.com"}, other); err != nil {
+		t.Fatalf("request from another IP: %v", err)
+	}
+}
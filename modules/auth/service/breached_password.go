@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedPasswordChecker tells whether a password is known from a data
+// breach.
+type BreachedPasswordChecker interface {
+	IsBreached(password string) (bool, error)
+}
+
+type noopBreachedPasswordChecker struct{}
+
+func (noopBreachedPasswordChecker) IsBreached(string) (bool, error) {
+	return false, nil
+}
+
+// fileBreachedPasswordChecker looks passwords up in a local copy of the
+// Pwned Passwords SHA-1 list, the k-anonymity ranges concatenated into one
+// file sorted by hash with one HASH:COUNT line per entry. The file is binary
+// searched in place, it is far too large to load.
+type fileBreachedPasswordChecker struct {
+	file *os.File
+	size int64
+}
+
+// NewBreachedPasswordChecker opens the list at path. An empty path disables
+// the check.
+func NewBreachedPasswordChecker(path string) (BreachedPasswordChecker, error) {
+	if path == "" {
+		return noopBreachedPasswordChecker{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fileBreachedPasswordChecker{
+		file: file,
+		size: info.Size(),
+	}, nil
+}
+
+func (c *fileBreachedPasswordChecker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	target := []byte(strings.ToUpper(hex.EncodeToString(sum[:])))
+
+	// Search the lines starting in [low, high), low is always a line start
+	low, high := int64(0), c.size
+	for low < high {
+		start, line, err := c.lineFrom(low + (high-low)/2)
+		if err != nil {
+			return false, err
+		}
+
+		if start >= high {
+			high = low + (high-low)/2
+			continue
+		}
+
+		hash, _, _ := bytes.Cut(bytes.TrimRight(line, "\r\n"), []byte(":"))
+		switch bytes.Compare(bytes.ToUpper(hash), target) {
+		case 0:
+			return true, nil
+		case -1:
+			low = start + int64(len(line))
+		default:
+			high = start
+		}
+	}
+
+	return false, nil
+}
+
+// lineFrom returns the first line starting at or after offset
+func (c *fileBreachedPasswordChecker) lineFrom(offset int64) (int64, []byte, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(c.file, start, c.size-start))
+	if offset > 0 {
+		skipped, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return c.size, nil, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return 0, nil, err
+	}
+
+	return start, line, nil
+}
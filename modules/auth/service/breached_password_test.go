@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachedList writes the hashes of the passwords sorted, one HASH:COUNT
+// line each, and returns the file and the passwords sorted by hash
+func writeBreachedList(t *testing.T, passwords []string, trailingNewline bool) (string, []string) {
+	t.Helper()
+
+	sorted := append([]string(nil), passwords...)
+	sort.Slice(sorted, func(i, j int) bool { return sha1Hex(sorted[i]) < sha1Hex(sorted[j]) })
+
+	lines := make([]string, 0, len(sorted))
+	for i, password := range sorted {
+		lines = append(lines, fmt.Sprintf("%s:%d", sha1Hex(password), i+1))
+	}
+	content := strings.Join(lines, "\r\n")
+	if trailingNewline {
+		content += "\r\n"
+	}
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path, sorted
+}
+
+func TestBreachedPasswordCheckerSearchesSortedHashes(t *testing.T) {
+	var passwords []string
+	for i := range 50 {
+		passwords = append(passwords, fmt.Sprintf("password-%d", i))
+	}
+
+	for _, trailingNewline := range []bool{true, false} {
+		path, sorted := writeBreachedList(t, passwords, trailingNewline)
+
+		checker, err := NewBreachedPasswordChecker(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name     string
+			password string
+			want     bool
+		}{
+			{"first entry", sorted[0], true},
+			{"last entry", sorted[len(sorted)-1], true},
+			{"middle entry", sorted[len(sorted)/2], true},
+			{"hit", sorted[7], true},
+			{"miss", "not-in-the-list", false},
+			{"another miss", "correct horse battery staple", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/trailing newline %v", tt.name, trailingNewline), func(t *testing.T) {
+				got, err := checker.IsBreached(tt.password)
+				if err != nil {
+					t.Fatalf("IsBreached: %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("IsBreached(%q) = %v, want %v", tt.password, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestBreachedPasswordCheckerFindsEveryEntry(t *testing.T) {
+	var passwords []string
+	for i := range 20 {
+		passwords = append(passwords, fmt.Sprintf("secret-%d", i))
+	}
+	path, _ := writeBreachedList(t, passwords, true)
+
+	checker, err := NewBreachedPasswordChecker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range passwords {
+		if breached, err := checker.IsBreached(password); err != nil || !breached {
+			t.Errorf("IsBreached(%q) = %v, %v", password, breached, err)
+		}
+	}
+}
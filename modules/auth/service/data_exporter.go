@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+
+	authRepo "blog/modules/auth/repository"
+	"blog/pkg/export"
+	"gorm.io/gorm"
+)
+
+// NewSessionExporter exports the active sessions of a user
+func NewSessionExporter(refreshTokenRepo authRepo.RefreshTokenRepository, db *gorm.DB) export.Exporter {
+	return export.NewExporter(
+		"sessions",
+		func(ctx context.Context, userId string) (int64, error) {
+			return refreshTokenRepo.CountActiveByUserID(ctx, db, userId)
+		},
+		func(ctx context.Context, userId string) ([]export.Record, error) {
+			refreshTokens, err := refreshTokenRepo.FindActiveByUserID(ctx, db, userId)
+			if err != nil {
+				return nil, err
+			}
+			return export.ToRecords(refreshTokens)
+		},
+	)
+}
+
+// NewLinkedIdentityExporter exports the social logins linked to a user
+func NewLinkedIdentityExporter(linkedIdentityRepo authRepo.LinkedIdentityRepository, db *gorm.DB) export.Exporter {
+	return export.NewExporter(
+		"linked_identities",
+		func(ctx context.Context, userId string) (int64, error) {
+			return linkedIdentityRepo.CountByUserID(ctx, db, userId)
+		},
+		func(ctx context.Context, userId string) ([]export.Record, error) {
+			identities, err := linkedIdentityRepo.FindByUserID(ctx, db, userId)
+			if err != nil {
+				return nil, err
+			}
+			return export.ToRecords(identities)
+		},
+	)
+}
+
+// NewPasskeyExporter exports the passkeys of a user, without key material
+func NewPasskeyExporter(webAuthnCredentialRepo authRepo.WebAuthnCredentialRepository, db *gorm.DB) export.Exporter {
+	return export.NewExporter(
+		"passkeys",
+		func(ctx context.Context, userId string) (int64, error) {
+			return webAuthnCredentialRepo.CountByUserID(ctx, db, userId)
+		},
+		func(ctx context.Context, userId string) ([]export.Record, error) {
+			credentials, err := webAuthnCredentialRepo.FindByUserID(ctx, db, userId)
+			if err != nil {
+				return nil, err
+			}
+			return export.ToRecords(credentials)
+		},
+	)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/user/repository"
+	"blog/pkg/cache"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errNoDatabase = errors.New("tests run without a database")
+
+// noopConnPool lets gorm open transactions without a database. The fake
+// repositories never send it a query.
+type noopConnPool struct{}
+
+// noopTx is the transaction noopConnPool begins. It is a separate type so
+// gorm does not mistake the pool itself for an open transaction.
+type noopTx struct{ noopConnPool }
+
+func (noopConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errNoDatabase
+}
+
+func (noopConnPool) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, errNoDatabase
+}
+
+func (noopConnPool) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, errNoDatabase
+}
+
+func (noopConnPool) QueryRowContext(context.Context, string, ...any) *sql.Row {
+	return nil
+}
+
+func (noopConnPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &noopTx{}, nil
+}
+
+func (*noopTx) Commit() error   { return nil }
+func (*noopTx) Rollback() error { return nil }
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: noopConnPool{}}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestJWTService(t *testing.T) JWTService {
+	t.Helper()
+
+	cfg, err := config.NewJWTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Secret = "test"
+	cfg.RefreshSecret = "test"
+
+	jwtService, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtService
+}
+
+func newTestRevocationService(t *testing.T, jwtService JWTService) TokenRevocationService {
+	t.Helper()
+	return NewTokenRevocationService(cache.NewMemoryStore(), jwtService)
+}
+
+// fakeUserRepository keeps users in memory. Methods not overridden here
+// panic through the nil embedded interface, which flags a test relying on
+// something it did not set up.
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu    sync.Mutex
+	users map[string]entities.User
+}
+
+func newFakeUserRepository(users ...entities.User) *fakeUserRepository {
+	r := &fakeUserRepository{users: make(map[string]entities.User)}
+	for _, user := range users {
+		r.users[user.ID.String()] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepository) GetUserById(_ context.Context, _ *gorm.DB, userId string) (entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return entities.User{}, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetUserByEmail(_ context.Context, _ *gorm.DB, email string) (entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return entities.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) Register(_ context.Context, _ *gorm.DB, user entities.User) (entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return entities.User{}, gorm.ErrDuplicatedKey
+		}
+	}
+	r.users[user.ID.String()] = user
+	return user, nil
+}
+
+func (r *fakeUserRepository) UpdateColumns(_ context.Context, _ *gorm.DB, userId string, columns map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userId]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	for column, value := range columns {
+		switch column {
+		case "is_verified":
+			user.IsVerified = value.(bool)
+		case "password":
+			user.Password = value.(string)
+		case "email":
+			user.Email = value.(string)
+		default:
+			panic("fakeUserRepository: unsupported column " + column)
+		}
+	}
+	r.users[userId] = user
+	return nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blog/modules/auth/dto"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const defaultHMACKeyID = "default"
+
+var ErrNoSigningKey = errors.New("no jwt signing key configured")
+
+// signingKey is one key of the key set. Keys being rotated out keep verifying
+// tokens until retireAt so tokens signed just before a rotation stay valid.
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	retireAt  time.Time
+}
+
+func (k *signingKey) retired(now time.Time) bool {
+	return !k.retireAt.IsZero() && now.After(k.retireAt)
+}
+
+type keySet struct {
+	mu      sync.RWMutex
+	current *signingKey
+	keys    map[string]*signingKey
+	grace   time.Duration
+}
+
+func newKeySet(grace time.Duration) *keySet {
+	return &keySet{
+		keys:  make(map[string]*signingKey),
+		grace: grace,
+	}
+}
+
+// add registers a key. The first key added becomes the signing key, the
+// others are only used for verification until the grace period ends.
+func (k *keySet) add(key *signingKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.current == nil {
+		k.current = key
+	} else if key.retireAt.IsZero() {
+		key.retireAt = time.Now().Add(k.grace)
+	}
+	k.keys[key.id] = key
+}
+
+// rotate makes key the signing key and schedules the previous one for retirement
+func (k *keySet) rotate(key *signingKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if k.current != nil {
+		k.current.retireAt = now.Add(k.grace)
+	}
+
+	for id, existing := range k.keys {
+		if existing.retired(now) {
+			delete(k.keys, id)
+		}
+	}
+
+	k.current = key
+	k.keys[key.id] = key
+}
+
+func (k *keySet) signer() (*signingKey, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.current == nil {
+		return nil, ErrNoSigningKey
+	}
+	return k.current, nil
+}
+
+func (k *keySet) lookup(kid string) (*signingKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	// Tokens issued before kid headers were introduced were signed with the HMAC secret
+	if kid == "" {
+		kid = defaultHMACKeyID
+	}
+
+	key, ok := k.keys[kid]
+	if !ok || key.retired(time.Now()) {
+		return nil, false
+	}
+	return key, true
+}
+
+func (k *keySet) methods() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range k.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// jwks returns the public keys that can currently verify tokens. HMAC keys are
+// shared secrets and are never published.
+func (k *keySet) jwks() dto.JSONWebKeySet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	now := time.Now()
+	set := dto.JSONWebKeySet{Keys: []dto.JSONWebKey{}}
+	for _, key := range k.keys {
+		if key.retired(now) {
+			continue
+		}
+
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, dto.JSONWebKey{
+				Kty: "RSA",
+				Kid: key.id,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, dto.JSONWebKey{
+				Kty: "OKP",
+				Kid: key.id,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+func newHMACKey(id string, secret []byte) *signingKey {
+	return &signingKey{
+		id:        id,
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// generateSigningKey creates a fresh key for the given algorithm, used by scheduled rotation
+func generateSigningKey(alg string) (*signingKey, error) {
+	id := uuid.NewString()
+
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: public}, nil
+	case jwt.SigningMethodHS256.Alg():
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return newHMACKey(id, secret), nil
+	default:
+		return nil, fmt.Errorf("unsupported jwt signing algorithm %q", alg)
+	}
+}
+
+// loadSigningKey reads a PEM encoded private key. The key ID is the file name
+// without its extension.
+func loadSigningKey(alg string, path string) (*signingKey, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}, nil
+	case jwt.SigningMethodEdDSA.Alg():
+		key, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 private key", path)
+		}
+		return &signingKey{id: id, method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwt signing algorithm %q", alg)
+	}
+}
@@ -3,68 +3,238 @@ package service
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"blog/config"
+	"blog/modules/auth/dto"
+	"blog/pkg/constants"
+	"blog/pkg/helpers"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type JWTService interface {
-	GenerateAccessToken(userId string, role string) string
+	GenerateAccessToken(userId string, role string, sessionId string) string
+	GenerateChallengeToken(userId string, purpose string, expiry time.Duration) (string, error)
+	GenerateClientAccessToken(userId string, clientId string, scope string) (string, error)
 	GenerateRefreshToken() (string, time.Time)
-	ValidateToken(token string) (*jwt.Token, error)
+	HashRefreshToken(token string) string
+	AccessTokenExpiry() time.Duration
+	AccessTokenValidity() time.Duration
+	Leeway() time.Duration
+	JWKS() dto.JSONWebKeySet
+	ValidateToken(token string) (*JWTCustomClaim, error)
 	GetUserIDByToken(token string) (string, error)
 }
 
-type jwtCustomClaim struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+// JWTCustomClaim is the payload of every token signed by JWTService
+type JWTCustomClaim struct {
+	UserID    string `json:"user_id"`
+	Role      string `json:"role"`
+	Purpose   string `json:"purpose"`
+	SessionID string `json:"session_id,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasScopes reports whether the scope of the token covers every given scope.
+// Tokens without a scope, like the ones issued at login, are not narrowed.
+func (c *JWTCustomClaim) HasScopes(scopes ...string) bool {
+	if c.Scope == "" {
+		return true
+	}
+
+	granted := strings.Fields(c.Scope)
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// jwt.TimePrecision is global to the jwt package, so it is set once when this
+// package loads rather than by every JWTService. iat is compared against user
+// revocation cutoffs, whole seconds would let a token issued in the same
+// second as a revocation through.
+func init() {
+	jwt.TimePrecision = time.Millisecond
+}
+
 type jwtService struct {
-	secretKey     string
+	keys          *keySet
+	refreshKey    string
 	issuer        string
+	audience      string
+	leeway        time.Duration
 	accessExpiry  time.Duration
 	refreshExpiry time.Duration
 }
 
-func NewJWTService() JWTService {
-	return &jwtService{
-		secretKey:     getSecretKey(),
-		issuer:        "Template",
-		accessExpiry:  time.Minute * 15,
-		refreshExpiry: time.Hour * 24 * 7,
+func NewJWTService(cfg *config.JWTConfig) (JWTService, error) {
+	production := os.Getenv("APP_ENV") == constants.ENUM_RUN_PRODUCTION
+
+	refreshKey := cfg.RefreshSecret
+	if refreshKey == "" {
+		if production {
+			return nil, errors.New("REFRESH_TOKEN_SECRET or JWT_SECRET must be set in production")
+		}
+		refreshKey = "Template"
 	}
+
+	keys, err := loadKeySet(cfg, production)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &jwtService{
+		keys:          keys,
+		refreshKey:    refreshKey,
+		issuer:        cfg.Issuer,
+		audience:      cfg.Audience,
+		leeway:        cfg.Leeway,
+		accessExpiry:  cfg.AccessExpiry,
+		refreshExpiry: cfg.RefreshExpiry,
+	}
+
+	if cfg.RotationInterval > 0 {
+		go j.rotateKeys(cfg.SigningAlg, cfg.RotationInterval)
+	}
+
+	return j, nil
 }
 
-func getSecretKey() string {
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		secretKey = "Template"
+// loadKeySet builds the signing keys from the configuration. HS256 uses the
+// shared secret; RS256 and EdDSA read the configured PEM files, the first one
+// signing and the others only verifying during the grace period.
+func loadKeySet(cfg *config.JWTConfig, production bool) (*keySet, error) {
+	keys := newKeySet(cfg.GracePeriod)
+
+	if cfg.SigningAlg == jwt.SigningMethodHS256.Alg() {
+		secret := cfg.Secret
+		if secret == "" {
+			if production {
+				return nil, ErrNoSigningKey
+			}
+			secret = "Template"
+		}
+		keys.add(newHMACKey(defaultHMACKeyID, []byte(secret)))
+		return keys, nil
 	}
-	return secretKey
+
+	for _, path := range cfg.PrivateKeys {
+		key, err := loadSigningKey(cfg.SigningAlg, path)
+		if err != nil {
+			return nil, err
+		}
+		keys.add(key)
+	}
+
+	if _, err := keys.signer(); err != nil {
+		if production {
+			return nil, err
+		}
+
+		// Outside production an ephemeral key keeps local setups working
+		key, err := generateSigningKey(cfg.SigningAlg)
+		if err != nil {
+			return nil, err
+		}
+		keys.add(key)
+	}
+
+	return keys, nil
 }
 
-func (j *jwtService) GenerateAccessToken(userId string, role string) string {
-	claims := jwtCustomClaim{
-		userId,
-		role,
-		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.accessExpiry)),
-			Issuer:    j.issuer,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// rotateKeys replaces the signing key on every tick. Generated keys only live in
+// this process, so with several instances keys should be rotated through
+// JWT_PRIVATE_KEYS instead.
+func (j *jwtService) rotateKeys(alg string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		key, err := generateSigningKey(alg)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		j.keys.rotate(key)
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tx, err := token.SignedString([]byte(j.secretKey))
+func (j *jwtService) GenerateAccessToken(userId string, role string, sessionId string) string {
+	token, err := j.signToken(JWTCustomClaim{
+		UserID:    userId,
+		Role:      role,
+		Purpose:   constants.ENUM_TOKEN_PURPOSE_ACCESS,
+		SessionID: sessionId,
+	}, j.accessExpiry)
 	if err != nil {
 		log.Println(err)
 	}
-	return tx
+	return token
+}
+
+// GenerateChallengeToken signs a short-lived token for an intermediate step,
+// such as a login waiting for its second factor. Its purpose keeps it from
+// being accepted as an access token.
+func (j *jwtService) GenerateChallengeToken(userId string, purpose string, expiry time.Duration) (string, error) {
+	return j.signToken(JWTCustomClaim{
+		UserID:  userId,
+		Purpose: purpose,
+	}, expiry)
+}
+
+// GenerateClientAccessToken signs an access token for an OAuth client. The
+// subject is the user who granted it, or the client itself for the
+// client-credentials grant, and the scope is the space separated list granted.
+func (j *jwtService) GenerateClientAccessToken(userId string, clientId string, scope string) (string, error) {
+	claims := JWTCustomClaim{
+		UserID:   userId,
+		Purpose:  constants.ENUM_TOKEN_PURPOSE_OAUTH_ACCESS,
+		ClientID: clientId,
+		Scope:    scope,
+	}
+	claims.Subject = userId
+	if userId == "" {
+		claims.Subject = clientId
+	}
+
+	return j.signToken(claims, j.accessExpiry)
+}
+
+// signToken fills in the registered claims and signs with the current key
+func (j *jwtService) signToken(claims JWTCustomClaim, expiry time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Subject:   claims.Subject,
+		ID:        uuid.NewString(),
+		Issuer:    j.issuer,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+	}
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
+
+	key, err := j.keys.signer()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.signKey)
 }
 
 func (j *jwtService) GenerateRefreshToken() (string, time.Time) {
@@ -81,24 +251,91 @@ func (j *jwtService) GenerateRefreshToken() (string, time.Time) {
 	return refreshToken, expiresAt
 }
 
+func (j *jwtService) AccessTokenExpiry() time.Duration {
+	return j.accessExpiry
+}
+
+// AccessTokenValidity is how long after issuing an access token still passes
+// ValidateToken, its lifetime plus the leeway allowed past exp
+func (j *jwtService) AccessTokenValidity() time.Duration {
+	return j.accessExpiry + j.leeway
+}
+
+// Leeway is the clock skew ValidateToken allows on exp, iat and nbf
+func (j *jwtService) Leeway() time.Duration {
+	return j.leeway
+}
+
+// HashRefreshToken returns the keyed hash under which a refresh token is stored
+func (j *jwtService) HashRefreshToken(token string) string {
+	return helpers.HashTokenWithKey(token, j.refreshKey)
+}
+
+// JWKS returns the public keys other services use to verify our tokens
+func (j *jwtService) JWKS() dto.JSONWebKeySet {
+	return j.keys.jwks()
+}
+
 func (j *jwtService) parseToken(t_ *jwt.Token) (any, error) {
-	if _, ok := t_.Method.(*jwt.SigningMethodHMAC); !ok {
+	kid, _ := t_.Header["kid"].(string)
+	key, ok := j.keys.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if t_.Method.Alg() != key.method.Alg() {
 		return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
 	}
-	return []byte(j.secretKey), nil
+	return key.verifyKey, nil
 }
 
-func (j *jwtService) ValidateToken(token string) (*jwt.Token, error) {
-	return jwt.Parse(token, j.parseToken)
+// ValidateToken checks the signature and the registered claims, allowing for
+// the configured clock skew, and returns the typed claims
+func (j *jwtService) ValidateToken(token string) (*JWTCustomClaim, error) {
+	claims := &JWTCustomClaim{}
+	parser := jwt.NewParser(jwt.WithValidMethods(j.keys.methods()), jwt.WithoutClaimsValidation())
+	if _, err := parser.ParseWithClaims(token, claims, j.parseToken); err != nil {
+		return nil, err
+	}
+
+	if err := j.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (j *jwtService) validateClaims(claims *JWTCustomClaim) error {
+	now := time.Now()
+
+	if !claims.VerifyExpiresAt(now.Add(-j.leeway), true) {
+		return jwt.ErrTokenExpired
+	}
+
+	if !claims.VerifyIssuedAt(now.Add(j.leeway), false) {
+		return jwt.ErrTokenUsedBeforeIssued
+	}
+
+	if !claims.VerifyNotBefore(now.Add(j.leeway), false) {
+		return jwt.ErrTokenNotValidYet
+	}
+
+	if !claims.VerifyIssuer(j.issuer, true) {
+		return jwt.ErrTokenInvalidIssuer
+	}
+
+	if j.audience != "" && !claims.VerifyAudience(j.audience, true) {
+		return jwt.ErrTokenInvalidAudience
+	}
+
+	return nil
 }
 
 func (j *jwtService) GetUserIDByToken(token string) (string, error) {
-	tToken, err := j.ValidateToken(token)
+	claims, err := j.ValidateToken(token)
 	if err != nil {
 		return "", err
 	}
 
-	claims := tToken.Claims.(jwt.MapClaims)
-	id := fmt.Sprintf("%v", claims["user_id"])
-	return id, nil
-}
\ No newline at end of file
+	return claims.UserID, nil
+}
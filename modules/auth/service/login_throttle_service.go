@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/modules/auth/dto"
+	"blog/pkg/cache"
+)
+
+const (
+	loginFailuresKeyPrefix  = "login:failures:"
+	loginSuccessesKeyPrefix = "login:successes:"
+	loginLockedKeyPrefix    = "login:locked:"
+	loginDelayKeyPrefix     = "login:delay:"
+)
+
+// LoginThrottleService slows down and eventually locks out password guessing.
+// Failures are counted per account and per IP: each failure makes the next
+// attempt wait longer, and reaching the threshold locks the account (or IP)
+// until the lockout expires. Accounts are keyed by email, so guessing against
+// unknown addresses is throttled the same way.
+//
+// An attempt is counted by Attempt before the password is checked, so
+// parallel requests can not slip past the threshold; RecordSuccess takes it
+// back.
+type LoginThrottleService interface {
+	Attempt(ctx context.Context, email string, ip string) error
+	RecordFailure(ctx context.Context, email string, ip string) error
+	RecordSuccess(ctx context.Context, email string, ip string) error
+	Unlock(ctx context.Context, email string) error
+}
+
+type loginThrottleService struct {
+	config *config.LoginThrottleConfig
+	store  cache.Store
+}
+
+func NewLoginThrottleService(cfg *config.LoginThrottleConfig, store cache.Store) LoginThrottleService {
+	return &loginThrottleService{
+		config: cfg,
+		store:  store,
+	}
+}
+
+// Attempt refuses the attempt with a RetryAfterError while the account or IP
+// is locked or still has to wait after a previous failure. Otherwise the
+// attempt is counted as a failure until RecordSuccess says otherwise, and the
+// one past the threshold locks the account or IP.
+func (s *loginThrottleService) Attempt(ctx context.Context, email string, ip string) error {
+	account := accountKey(email)
+
+	if retryAfter, err := s.until(ctx, loginLockedKeyPrefix+account); err != nil || retryAfter > 0 {
+		return s.refuse(err, dto.ErrAccountLocked, retryAfter)
+	}
+
+	if ip != "" {
+		if retryAfter, err := s.until(ctx, loginLockedKeyPrefix+ipKey(ip)); err != nil || retryAfter > 0 {
+			return s.refuse(err, dto.ErrTooManyLoginAttempts, retryAfter)
+		}
+	}
+
+	if retryAfter, err := s.until(ctx, loginDelayKeyPrefix+account); err != nil || retryAfter > 0 {
+		return s.refuse(err, dto.ErrTooManyLoginAttempts, retryAfter)
+	}
+
+	failures, err := s.store.Incr(ctx, loginFailuresKeyPrefix+account, s.config.Window)
+	if err != nil {
+		return err
+	}
+	if failures > int64(s.config.MaxAttempts) {
+		if err := s.lock(ctx, account); err != nil {
+			return err
+		}
+		return s.refuse(nil, dto.ErrAccountLocked, s.config.LockoutDuration)
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	ipFailures, err := s.ipFailures(ctx, ip)
+	if err != nil {
+		return err
+	}
+	if ipFailures > int64(s.config.MaxIPAttempts) {
+		if err := s.lock(ctx, ipKey(ip)); err != nil {
+			return err
+		}
+		return s.refuse(nil, dto.ErrTooManyLoginAttempts, s.config.LockoutDuration)
+	}
+
+	return nil
+}
+
+// RecordFailure makes the account wait before its next attempt, or locks it
+// once the failures counted by Attempt reach the threshold
+func (s *loginThrottleService) RecordFailure(ctx context.Context, email string, ip string) error {
+	account := accountKey(email)
+
+	value, _, err := s.store.Get(ctx, loginFailuresKeyPrefix+account)
+	if err != nil {
+		return err
+	}
+	failures, _ := strconv.Atoi(value)
+
+	if failures >= s.config.MaxAttempts {
+		return s.lock(ctx, account)
+	}
+
+	return s.setUntil(ctx, loginDelayKeyPrefix+account, s.delay(max(failures, 1)))
+}
+
+// RecordSuccess clears the failures of the account. The attempt stays counted
+// for the IP but is offset by a success, so one valid account does not cover
+// for guessing against others.
+func (s *loginThrottleService) RecordSuccess(ctx context.Context, email string, ip string) error {
+	account := accountKey(email)
+	if err := s.store.Delete(ctx, loginFailuresKeyPrefix+account); err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, loginDelayKeyPrefix+account); err != nil {
+		return err
+	}
+
+	if ip == "" {
+		return nil
+	}
+
+	_, err := s.store.Incr(ctx, loginSuccessesKeyPrefix+ipKey(ip), s.config.Window)
+	return err
+}
+
+// Unlock lifts a lockout before it expires
+func (s *loginThrottleService) Unlock(ctx context.Context, email string) error {
+	account := accountKey(email)
+	for _, prefix := range []string{loginLockedKeyPrefix, loginFailuresKeyPrefix, loginDelayKeyPrefix} {
+		if err := s.store.Delete(ctx, prefix+account); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ipFailures counts an attempt from ip and returns the attempts of the window
+// that did not succeed. A new window drops the successes of the last one.
+func (s *loginThrottleService) ipFailures(ctx context.Context, ip string) (int64, error) {
+	attempts, err := s.store.Incr(ctx, loginFailuresKeyPrefix+ipKey(ip), s.config.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	successesKey := loginSuccessesKeyPrefix + ipKey(ip)
+	if attempts == 1 {
+		return attempts, s.store.Delete(ctx, successesKey)
+	}
+
+	value, _, err := s.store.Get(ctx, successesKey)
+	if err != nil {
+		return 0, err
+	}
+	successes, _ := strconv.ParseInt(value, 10, 64)
+
+	return attempts - successes, nil
+}
+
+func (s *loginThrottleService) lock(ctx context.Context, subject string) error {
+	if err := s.setUntil(ctx, loginLockedKeyPrefix+subject, s.config.LockoutDuration); err != nil {
+		return err
+	}
+
+	return s.store.Delete(ctx, loginFailuresKeyPrefix+subject)
+}
+
+// delay doubles with every failure: BaseDelay, 2*BaseDelay, 4*BaseDelay...
+func (s *loginThrottleService) delay(failures int) time.Duration {
+	delay := float64(s.config.BaseDelay) * math.Pow(2, float64(failures-1))
+	if delay > float64(s.config.MaxDelay) {
+		return s.config.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+// setUntil stores when a wait ends, so Attempt can report how long is left
+func (s *loginThrottleService) setUntil(ctx context.Context, key string, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	until := strconv.FormatInt(time.Now().Add(duration).UnixMilli(), 10)
+	return s.store.Set(ctx, key, until, duration)
+}
+
+func (s *loginThrottleService) until(ctx context.Context, key string) (time.Duration, error) {
+	value, found, err := s.store.Get(ctx, key)
+	if err != nil || !found {
+		return 0, err
+	}
+
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Until(time.UnixMilli(until)), nil
+}
+
+func (s *loginThrottleService) refuse(err error, reason error, retryAfter time.Duration) error {
+	if err != nil {
+		return err
+	}
+
+	return &dto.RetryAfterError{Err: reason, RetryAfter: retryAfter}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/cache"
+	"blog/pkg/helpers"
+	"github.com/google/uuid"
+)
+
+func newThrottledAuthService(t *testing.T, users ...entities.User) AuthService {
+	t.Helper()
+
+	// Without delays between failures only the attempt counts hold a burst back
+	throttle := NewLoginThrottleService(&config.LoginThrottleConfig{
+		MaxAttempts:     5,
+		MaxIPAttempts:   20,
+		Window:          time.Minute * 15,
+		LockoutDuration: time.Minute * 15,
+	}, cache.NewMemoryStore())
+
+	return NewAuthService(
+		newFakeUserRepository(users...),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		throttle,
+		nil,
+		&config.MagicLinkConfig{},
+		cache.NewMemoryStore(),
+		newTestDB(t),
+	)
+}
+
+// loginConcurrently sends n logins at once and returns how many were checked
+// rather than refused by the throttle
+func loginConcurrently(t *testing.T, service AuthService, n int, request func(i int) (userDto.UserLoginRequest, dto.SessionInfo)) int {
+	t.Helper()
+
+	var (
+		wg      sync.WaitGroup
+		checked atomic.Int32
+	)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req, session := request(i)
+			_, err := service.Login(context.Background(), req, session)
+
+			var retryErr *dto.RetryAfterError
+			switch {
+			case errors.As(err, &retryErr):
+			case errors.Is(err, dto.ErrInvalidCredentials), errors.Is(err, userDto.ErrEmailNotFound):
+				checked.Add(1)
+			default:
+				t.Errorf("login %d: %v", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return int(checked.Load())
+}
+
+func TestLoginThrottleLocksAccountUnderConcurrentGuesses(t *testing.T) {
+	hash, err := helpers.HashPassword("correct password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := entities.User{ID: uuid.New(), Email: "user@example.com", Password: hash, IsVerified: true}
+	service := newThrottledAuthService(t, user)
+
+	// Spread over many IPs so only the account limit applies
+	checked := loginConcurrently(t, service, 50, func(i int) (userDto.UserLoginRequest, dto.SessionInfo) {
+		return userDto.UserLoginRequest{Email: user.Email, Password: "guess " + strconv.Itoa(i)},
+			dto.SessionInfo{IPAddress: "192.0.2." + strconv.Itoa(i)}
+	})
+	if checked > 5 {
+		t.Errorf("%d concurrent guesses were checked, want at most 5", checked)
+	}
+
+	_, err = service.Login(context.Background(), userDto.UserLoginRequest{Email: user.Email, Password: "correct password"},
+		dto.SessionInfo{IPAddress: "198.51.100.1"})
+	if !errors.Is(err, dto.ErrAccountLocked) {
+		t.Errorf("login after the burst: %v, want the account locked", err)
+	}
+}
+
+func TestLoginThrottleLocksIPUnderConcurrentGuesses(t *testing.T) {
+	service := newThrottledAuthService(t)
+
+	// Spread over many accounts so only the IP limit applies
+	checked := loginConcurrently(t, service, 60, func(i int) (userDto.UserLoginRequest, dto.SessionInfo) {
+		return userDto.UserLoginRequest{Email: "user" + strconv.Itoa(i) + "@example.com", Password: "guess"},
+			dto.SessionInfo{IPAddress: "192.0.2.1"}
+	})
+	if checked > 20 {
+		t.Errorf("%d concurrent guesses were checked, want at most 20", checked)
+	}
+
+	_, err := service.Login(context.Background(), userDto.UserLoginRequest{Email: "other@example.com", Password: "guess"},
+		dto.SessionInfo{IPAddress: "192.0.2.1"})
+	if !errors.Is(err, dto.ErrTooManyLoginAttempts) {
+		t.Errorf("login after the burst: %v, want the IP locked", err)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"blog/config"
+	"golang.org/x/oauth2"
+)
+
+const oauthHTTPTimeout = time.Second * 10
+
+// OAuthIdentity is what a provider tells us about the user who signed in
+type OAuthIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OAuthProvider runs the authorization code flow (with PKCE) against one
+// social login provider
+type OAuthProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state string, verifier string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string) (OAuthIdentity, error)
+}
+
+func NewOAuthProviders(configs []config.OAuthProviderConfig) ([]OAuthProvider, error) {
+	client := &http.Client{Timeout: oauthHTTPTimeout}
+
+	providers := make([]OAuthProvider, 0, len(configs))
+	for _, cfg := range configs {
+		switch cfg.Kind {
+		case config.OAuthKindOIDC:
+			providers = append(providers, &oidcProvider{config: cfg, client: client})
+		case config.OAuthKindGitHub:
+			providers = append(providers, &githubProvider{config: cfg, client: client})
+		default:
+			return nil, fmt.Errorf("unknown oauth provider kind %q", cfg.Kind)
+		}
+	}
+
+	return providers, nil
+}
+
+// oidcProvider reads the identity from the userinfo endpoint, which is called
+// with the access token obtained directly from the provider. Endpoints are
+// discovered from the issuer on first use unless they are configured.
+type oidcProvider struct {
+	config config.OAuthProviderConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	oauth2   *oauth2.Config
+	userInfo string
+}
+
+func (p *oidcProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *oidcProvider) AuthCodeURL(ctx context.Context, state string, verifier string) (string, error) {
+	conf, _, err := p.endpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return conf.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code string, verifier string) (OAuthIdentity, error) {
+	conf, userInfoURL, err := p.endpoints(ctx)
+	if err != nil {
+		return OAuthIdentity{}, err
+	}
+
+	token, err := conf.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return OAuthIdentity{}, err
+	}
+
+	var claims struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified any    `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, userInfoURL, token.AccessToken, &claims); err != nil {
+		return OAuthIdentity{}, err
+	}
+
+	if claims.Subject == "" {
+		return OAuthIdentity{}, errors.New("userinfo response has no subject")
+	}
+
+	// Some providers send email_verified as a string
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+
+	return OAuthIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *oidcProvider) endpoints(ctx context.Context) (*oauth2.Config, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth2 != nil {
+		return p.oauth2, p.userInfo, nil
+	}
+
+	authURL, tokenURL, userInfoURL := p.config.AuthURL, p.config.TokenURL, p.config.UserInfoURL
+	if authURL == "" || tokenURL == "" || userInfoURL == "" {
+		var discovery struct {
+			Issuer                string `json:"issuer"`
+			AuthorizationEndpoint string `json:"authorization_endpoint"`
+			TokenEndpoint         string `json:"token_endpoint"`
+			UserInfoEndpoint      string `json:"userinfo_endpoint"`
+		}
+
+		issuer := strings.TrimSuffix(p.config.Issuer, "/")
+		if err := getJSON(ctx, p.client, issuer+"/.well-known/openid-configuration", "", &discovery); err != nil {
+			return nil, "", err
+		}
+
+		if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+			return nil, "", fmt.Errorf("discovery document issuer %q does not match %q", discovery.Issuer, issuer)
+		}
+
+		authURL = firstNonEmpty(authURL, discovery.AuthorizationEndpoint)
+		tokenURL = firstNonEmpty(tokenURL, discovery.TokenEndpoint)
+		userInfoURL = firstNonEmpty(userInfoURL, discovery.UserInfoEndpoint)
+	}
+
+	p.oauth2 = &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		},
+	}
+	p.userInfo = userInfoURL
+
+	return p.oauth2, p.userInfo, nil
+}
+
+// githubProvider uses the REST API since GitHub does not implement OIDC for
+// user sign-in. Only the primary address counts, and only once verified.
+type githubProvider struct {
+	config config.OAuthProviderConfig
+	client *http.Client
+}
+
+func (p *githubProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *githubProvider) oauth2Config() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Scopes:       p.config.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  p.config.AuthURL,
+			TokenURL: p.config.TokenURL,
+		},
+	}
+}
+
+func (p *githubProvider) AuthCodeURL(_ context.Context, state string, verifier string) (string, error) {
+	return p.oauth2Config().AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code string, verifier string) (OAuthIdentity, error) {
+	token, err := p.oauth2Config().Exchange(
+		context.WithValue(ctx, oauth2.HTTPClient, p.client),
+		code,
+		oauth2.VerifierOption(verifier),
+	)
+	if err != nil {
+		return OAuthIdentity{}, err
+	}
+
+	apiURL := strings.TrimSuffix(p.config.UserInfoURL, "/")
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, apiURL+"/user", token.AccessToken, &user); err != nil {
+		return OAuthIdentity{}, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, apiURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return OAuthIdentity{}, err
+	}
+
+	identity := OAuthIdentity{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Name:    firstNonEmpty(user.Name, user.Login),
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, accessToken string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: %s: %s", url, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
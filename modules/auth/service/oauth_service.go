@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	userDto "blog/modules/user/dto"
+	"blog/modules/user/repository"
+	"blog/pkg/cache"
+	"blog/pkg/constants"
+	"blog/pkg/helpers"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const (
+	oauthStateKeyPrefix = "oauth:state:"
+	oauthStateExpiry    = time.Minute * 10
+)
+
+type OAuthService interface {
+	AuthorizationURL(ctx context.Context, provider string) (dto.OAuthAuthorization, error)
+	Callback(ctx context.Context, provider string, req dto.OAuthCallbackRequest, session dto.SessionInfo) (dto.LoginResponse, error)
+}
+
+type oauthService struct {
+	providers                map[string]OAuthProvider
+	userRepository           repository.UserRepository
+	linkedIdentityRepository authRepo.LinkedIdentityRepository
+	tokenIssuer              *tokenIssuer
+	store                    cache.Store
+	db                       *gorm.DB
+}
+
+func NewOAuthService(
+	providers []OAuthProvider,
+	userRepo repository.UserRepository,
+	linkedIdentityRepo authRepo.LinkedIdentityRepository,
+	refreshTokenRepo authRepo.RefreshTokenRepository,
+	jwtService JWTService,
+	twoFactorService TwoFactorService,
+	store cache.Store,
+	db *gorm.DB,
+) OAuthService {
+	byName := make(map[string]OAuthProvider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &oauthService{
+		providers:                byName,
+		userRepository:           userRepo,
+		linkedIdentityRepository: linkedIdentityRepo,
+		tokenIssuer:              newTokenIssuer(refreshTokenRepo, jwtService, twoFactorService, db),
+		store:                    store,
+		db:                       db,
+	}
+}
+
+// AuthorizationURL starts a login at the provider. The state and the PKCE
+// verifier stay on the server until the provider redirects back; a hash of
+// the state is handed out to be kept in a cookie, so only the browser that
+// started the login can finish it.
+func (s *oauthService) AuthorizationURL(ctx context.Context, providerName string) (dto.OAuthAuthorization, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return dto.OAuthAuthorization{}, dto.ErrOAuthProviderNotFound
+	}
+
+	state, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return dto.OAuthAuthorization{}, err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+	if err := s.store.Set(ctx, oauthStateKeyPrefix+state, providerName+" "+verifier, oauthStateExpiry); err != nil {
+		return dto.OAuthAuthorization{}, err
+	}
+
+	url, err := provider.AuthCodeURL(ctx, state, verifier)
+	if err != nil {
+		return dto.OAuthAuthorization{}, err
+	}
+
+	return dto.OAuthAuthorization{
+		URL:          url,
+		StateBinding: helpers.HashToken(state),
+		ExpiresIn:    oauthStateExpiry,
+	}, nil
+}
+
+// Callback finishes the login. A known identity signs in its user; otherwise
+// the identity is linked to the verified user with the same verified email,
+// or a new user is created for it.
+func (s *oauthService) Callback(
+	ctx context.Context,
+	providerName string,
+	req dto.OAuthCallbackRequest,
+	session dto.SessionInfo,
+) (dto.LoginResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return dto.LoginResponse{}, dto.ErrOAuthProviderNotFound
+	}
+
+	// A callback URL started in another browser would sign this one in to
+	// whoever started it
+	binding := helpers.HashToken(req.State)
+	if req.StateBinding == "" || subtle.ConstantTimeCompare([]byte(binding), []byte(req.StateBinding)) != 1 {
+		return dto.LoginResponse{}, dto.ErrOAuthStateInvalid
+	}
+
+	verifier, err := s.consumeState(ctx, providerName, req.State)
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	identity, err := provider.Exchange(ctx, req.Code, verifier)
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	var user entities.User
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err = s.resolveUser(ctx, tx, providerName, identity)
+		return err
+	})
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	return s.tokenIssuer.login(ctx, user, session)
+}
+
+// consumeState returns the PKCE verifier of a state, which can only be used once
+func (s *oauthService) consumeState(ctx context.Context, providerName string, state string) (string, error) {
+	key := oauthStateKeyPrefix + state
+
+	value, found, err := s.store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", dto.ErrOAuthStateInvalid
+	}
+
+	if err := s.store.Delete(ctx, key); err != nil {
+		return "", err
+	}
+
+	name, verifier, ok := strings.Cut(value, " ")
+	if !ok || name != providerName {
+		return "", dto.ErrOAuthStateInvalid
+	}
+
+	return verifier, nil
+}
+
+func (s *oauthService) resolveUser(
+	ctx context.Context,
+	tx *gorm.DB,
+	providerName string,
+	identity OAuthIdentity,
+) (entities.User, error) {
+	linked, err := s.linkedIdentityRepository.FindByProviderSubject(ctx, tx, providerName, identity.Subject)
+	if err == nil {
+		// The preload leaves User empty once the user is deleted
+		if linked.User.ID == uuid.Nil {
+			return entities.User{}, userDto.ErrUserNotFound
+		}
+		return linked.User, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.User{}, err
+	}
+
+	// Linking on an unverified address would let anyone claim an account
+	if identity.Email == "" || !identity.EmailVerified {
+		return entities.User{}, dto.ErrOAuthEmailNotVerified
+	}
+
+	user, err := s.userRepository.GetUserByEmail(ctx, tx, identity.Email)
+	switch {
+	case err == nil:
+		// Whoever registered an unverified account may not own the address;
+		// linking it would hand them a session of the real owner
+		if !user.IsVerified {
+			return entities.User{}, dto.ErrOAuthAccountUnverified
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user, err = s.createUser(ctx, tx, identity)
+		if err != nil {
+			return entities.User{}, err
+		}
+	default:
+		return entities.User{}, err
+	}
+
+	_, err = s.linkedIdentityRepository.Create(ctx, tx, entities.LinkedIdentity{
+		ID:       uuid.New(),
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	return user, nil
+}
+
+// createUser registers a user without a usable password; they can set one
+// through the password reset flow
+func (s *oauthService) createUser(ctx context.Context, tx *gorm.DB, identity OAuthIdentity) (entities.User, error) {
+	password, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return entities.User{}, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	name = helpers.TruncateRunes(name, 100)
+
+	return s.userRepository.Register(ctx, tx, entities.User{
+		ID:         uuid.New(),
+		Name:       name,
+		Email:      identity.Email,
+		Password:   password,
+		Role:       constants.ENUM_ROLE_USER,
+		IsVerified: true,
+	})
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	"blog/pkg/cache"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeLinkedIdentityRepository struct {
+	authRepo.LinkedIdentityRepository
+
+	mu         sync.Mutex
+	users      *fakeUserRepository
+	identities []entities.LinkedIdentity
+}
+
+func (r *fakeLinkedIdentityRepository) Create(
+	_ context.Context,
+	_ *gorm.DB,
+	identity entities.LinkedIdentity,
+) (entities.LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.identities = append(r.identities, identity)
+	return identity, nil
+}
+
+func (r *fakeLinkedIdentityRepository) FindByProviderSubject(
+	ctx context.Context,
+	_ *gorm.DB,
+	provider string,
+	subject string,
+) (entities.LinkedIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			identity.User, _ = r.users.GetUserById(ctx, nil, identity.UserID.String())
+			return identity, nil
+		}
+	}
+	return entities.LinkedIdentity{}, gorm.ErrRecordNotFound
+}
+
+type fakeRefreshTokenRepository struct {
+	authRepo.RefreshTokenRepository
+}
+
+func (fakeRefreshTokenRepository) Create(
+	_ context.Context,
+	_ *gorm.DB,
+	token entities.RefreshToken,
+) (entities.RefreshToken, error) {
+	return token, nil
+}
+
+// fakeOIDCServer is a provider with discovery, a token endpoint that enforces
+// PKCE and a userinfo endpoint answering with the claims set by the test
+type fakeOIDCServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	challenges map[string]string
+	claims     map[string]any
+}
+
+const fakeOIDCAccessToken = "access-token"
+
+func newFakeOIDCServer(t *testing.T) *fakeOIDCServer {
+	t.Helper()
+
+	s := &fakeOIDCServer{challenges: make(map[string]string)}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 s.URL,
+			"authorization_endpoint": s.URL + "/authorize",
+			"token_endpoint":         s.URL + "/token",
+			"userinfo_endpoint":      s.URL + "/userinfo",
+		})
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		challenge, ok := s.challenges[r.PostForm.Get("code")]
+		delete(s.challenges, r.PostForm.Get("code"))
+		s.mu.Unlock()
+
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		writeJSON(w, map[string]string{"access_token": fakeOIDCAccessToken, "token_type": "Bearer"})
+	})
+
+	mux.HandleFunc("GET /userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+fakeOIDCAccessToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		writeJSON(w, s.claims)
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// authorize plays the user approving the login at the provider and returns
+// the code the provider redirects back with
+func (s *fakeOIDCServer) authorize(t *testing.T, authURL string) (code string, state string) {
+	t.Helper()
+
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+
+	if u.Path != "/authorize" || query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL %s does not use PKCE", authURL)
+	}
+
+	code = uuid.NewString()
+	s.mu.Lock()
+	s.challenges[code] = query.Get("code_challenge")
+	s.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func (s *fakeOIDCServer) setClaims(claims map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims = claims
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type oauthFixture struct {
+	service    OAuthService
+	server     *fakeOIDCServer
+	users      *fakeUserRepository
+	identities *fakeLinkedIdentityRepository
+}
+
+func newOAuthFixture(t *testing.T, users ...entities.User) oauthFixture {
+	t.Helper()
+
+	server := newFakeOIDCServer(t)
+	providers, err := NewOAuthProviders([]config.OAuthProviderConfig{{
+		Name:         "test",
+		Kind:         config.OAuthKindOIDC,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+		Issuer:       server.URL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := newFakeUserRepository(users...)
+	identities := &fakeLinkedIdentityRepository{users: userRepo}
+	jwtService := newTestJWTService(t)
+
+	service := NewOAuthService(
+		providers,
+		userRepo,
+		identities,
+		fakeRefreshTokenRepository{},
+		jwtService,
+		newTwoFactorFixture(t).service,
+		cache.NewMemoryStore(),
+		newTestDB(t),
+	)
+
+	return oauthFixture{service: service, server: server, users: userRepo, identities: identities}
+}
+
+func (f oauthFixture) login(t *testing.T, claims map[string]any) (dto.LoginResponse, error) {
+	t.Helper()
+
+	f.server.setClaims(claims)
+
+	authorization, err := f.service.AuthorizationURL(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	code, state := f.server.authorize(t, authorization.URL)
+
+	req := dto.OAuthCallbackRequest{Code: code, State: state, StateBinding: authorization.StateBinding}
+	return f.service.Callback(context.Background(), "test", req, dto.SessionInfo{})
+}
+
+func TestOAuthCallbackCreatesAndSignsInUser(t *testing.T) {
+	f := newOAuthFixture(t)
+	claims := map[string]any{"sub": "subject-1", "email": "new@example.com", "email_verified": true, "name": "New"}
+
+	response, err := f.login(t, claims)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if response.TokenResponse == nil || response.AccessToken == "" {
+		t.Fatal("first login issued no tokens")
+	}
+
+	user, err := f.users.GetUserByEmail(context.Background(), nil, "new@example.com")
+	if err != nil {
+		t.Fatalf("user was not created: %v", err)
+	}
+	if !user.IsVerified || user.Name != "New" {
+		t.Errorf("created user = %+v", user)
+	}
+
+	// The subject identifies the account from now on, even with another email
+	claims["email"] = "renamed@example.com"
+	if _, err := f.login(t, claims); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if len(f.users.users) != 1 || len(f.identities.identities) != 1 {
+		t.Errorf("second login created %d users and %d identities", len(f.users.users), len(f.identities.identities))
+	}
+}
+
+func TestOAuthCallbackLinksVerifiedEmail(t *testing.T) {
+	existing := entities.User{ID: uuid.New(), Name: "Existing", Email: "existing@example.com", IsVerified: true}
+	f := newOAuthFixture(t, existing)
+
+	// Some providers send email_verified as a string
+	_, err := f.login(t, map[string]any{"sub": "subject-1", "email": "existing@example.com", "email_verified": "true"})
+	if err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+
+	if len(f.users.users) != 1 {
+		t.Errorf("got %d users, want the existing one only", len(f.users.users))
+	}
+	if len(f.identities.identities) != 1 || f.identities.identities[0].UserID != existing.ID {
+		t.Errorf("identity not linked to the existing user: %+v", f.identities.identities)
+	}
+}
+
+func TestOAuthCallbackRejectsUnverifiedEmail(t *testing.T) {
+	existing := entities.User{ID: uuid.New(), Name: "Existing", Email: "existing@example.com", IsVerified: true}
+	f := newOAuthFixture(t, existing)
+
+	for _, verified := range []any{false, "false", nil} {
+		_, err := f.login(t, map[string]any{"sub": "subject-1", "email": "existing@example.com", "email_verified": verified})
+		if !errors.Is(err, dto.ErrOAuthEmailNotVerified) {
+			t.Errorf("email_verified %v: %v", verified, err)
+		}
+	}
+
+	if len(f.identities.identities) != 0 {
+		t.Errorf("unverified email linked %d identities", len(f.identities.identities))
+	}
+}
+
+func TestOAuthCallbackRejectsUnverifiedAccount(t *testing.T) {
+	// Registered by someone who never proved they own the address
+	squatter := entities.User{ID: uuid.New(), Name: "Squatter", Email: "victim@example.com", Password: "known"}
+	f := newOAuthFixture(t, squatter)
+
+	_, err := f.login(t, map[string]any{"sub": "subject-1", "email": "victim@example.com", "email_verified": true})
+	if !errors.Is(err, dto.ErrOAuthAccountUnverified) {
+		t.Fatalf("Callback: %v", err)
+	}
+
+	if len(f.identities.identities) != 0 || f.users.users[squatter.ID.String()].IsVerified {
+		t.Error("unverified account was linked or marked verified")
+	}
+}
+
+func TestOAuthCallbackEnforcesStateAndPKCE(t *testing.T) {
+	f := newOAuthFixture(t)
+	f.server.setClaims(map[string]any{"sub": "subject-1", "email": "new@example.com", "email_verified": true})
+	ctx := context.Background()
+
+	authorization, err := f.service.AuthorizationURL(ctx, "test")
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	code, state := f.server.authorize(t, authorization.URL)
+
+	// A state from another login carries another verifier
+	other, err := f.service.AuthorizationURL(ctx, "test")
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	_, otherState := f.server.authorize(t, other.URL)
+
+	req := dto.OAuthCallbackRequest{Code: code, State: otherState, StateBinding: other.StateBinding}
+	if _, err := f.service.Callback(ctx, "test", req, dto.SessionInfo{}); err == nil {
+		t.Fatal("code exchanged with the verifier of another login")
+	}
+
+	code, _ = f.server.authorize(t, authorization.URL)
+	req = dto.OAuthCallbackRequest{Code: code, State: state, StateBinding: authorization.StateBinding}
+	if _, err := f.service.Callback(ctx, "test", req, dto.SessionInfo{}); err != nil {
+		t.Fatalf("Callback: %v", err)
+	}
+
+	if _, err := f.service.Callback(ctx, "test", req, dto.SessionInfo{}); !errors.Is(err, dto.ErrOAuthStateInvalid) {
+		t.Fatalf("state reused: %v", err)
+	}
+}
+
+func TestOAuthCallbackRequiresTheStartingBrowser(t *testing.T) {
+	f := newOAuthFixture(t)
+	f.server.setClaims(map[string]any{"sub": "subject-1", "email": "attacker@example.com", "email_verified": true})
+	ctx := context.Background()
+
+	// The attacker starts a login and hands the callback URL to the victim,
+	// whose browser has no cookie or the one of its own login
+	attacker, err := f.service.AuthorizationURL(ctx, "test")
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	victim, err := f.service.AuthorizationURL(ctx, "test")
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	code, state := f.server.authorize(t, attacker.URL)
+
+	for _, binding := range []string{"", victim.StateBinding} {
+		req := dto.OAuthCallbackRequest{Code: code, State: state, StateBinding: binding}
+		if _, err := f.service.Callback(ctx, "test", req, dto.SessionInfo{}); !errors.Is(err, dto.ErrOAuthStateInvalid) {
+			t.Errorf("callback with binding %q: %v", binding, err)
+		}
+	}
+
+	if len(f.users.users) != 0 {
+		t.Errorf("callback from another browser created %d users", len(f.users.users))
+	}
+}
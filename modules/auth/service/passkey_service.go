@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	userDto "blog/modules/user/dto"
+	"blog/modules/user/repository"
+	"blog/pkg/cache"
+	"blog/pkg/helpers"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	passkeyCeremonyExpiry        = time.Minute * 5
+	passkeyRegistrationKeyPrefix = "webauthn:register:"
+	passkeyLoginKeyPrefix        = "webauthn:login:"
+	passkeyDefaultCredentialName = "Passkey"
+)
+
+// PasskeyService runs the WebAuthn registration and assertion ceremonies.
+// Both are started and finished in two calls, the challenge is kept in the
+// store in between.
+type PasskeyService interface {
+	BeginRegistration(ctx context.Context, userId string) (dto.PasskeyRegistrationResponse, error)
+	FinishRegistration(ctx context.Context, userId string, req dto.PasskeyRegisterRequest) (dto.PasskeyResponse, error)
+	ListPasskeys(ctx context.Context, userId string) ([]dto.PasskeyResponse, error)
+	DeletePasskey(ctx context.Context, userId string, id string) error
+	BeginLogin(ctx context.Context, req dto.PasskeyLoginBeginRequest) (dto.PasskeyLoginBeginResponse, error)
+	FinishLogin(ctx context.Context, req dto.PasskeyLoginFinishRequest, session dto.SessionInfo) (dto.TokenResponse, error)
+}
+
+type passkeyService struct {
+	webAuthn                     *webauthn.WebAuthn
+	webAuthnCredentialRepository authRepo.WebAuthnCredentialRepository
+	userRepository               repository.UserRepository
+	tokenIssuer                  *tokenIssuer
+	store                        cache.Store
+	db                           *gorm.DB
+}
+
+// NewWebAuthn builds the relying party from the configuration. User
+// verification is required, so a passkey counts as two factors on its own.
+func NewWebAuthn(cfg *config.WebAuthnConfig) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.RPOrigins,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationRequired,
+		},
+	})
+}
+
+func NewPasskeyService(
+	webAuthn *webauthn.WebAuthn,
+	webAuthnCredentialRepo authRepo.WebAuthnCredentialRepository,
+	userRepo repository.UserRepository,
+	refreshTokenRepo authRepo.RefreshTokenRepository,
+	jwtService JWTService,
+	twoFactorService TwoFactorService,
+	store cache.Store,
+	db *gorm.DB,
+) PasskeyService {
+	return &passkeyService{
+		webAuthn:                     webAuthn,
+		webAuthnCredentialRepository: webAuthnCredentialRepo,
+		userRepository:               userRepo,
+		tokenIssuer:                  newTokenIssuer(refreshTokenRepo, jwtService, twoFactorService, db),
+		store:                        store,
+		db:                           db,
+	}
+}
+
+func (s *passkeyService) BeginRegistration(ctx context.Context, userId string) (dto.PasskeyRegistrationResponse, error) {
+	user, err := s.loadUser(ctx, userId)
+	if err != nil {
+		return dto.PasskeyRegistrationResponse{}, err
+	}
+
+	// Authenticators already registered are excluded so they are not added twice
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	options, sessionData, err := s.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return dto.PasskeyRegistrationResponse{}, err
+	}
+
+	if err := s.saveCeremony(ctx, passkeyRegistrationKeyPrefix+userId, sessionData); err != nil {
+		return dto.PasskeyRegistrationResponse{}, err
+	}
+
+	return dto.PasskeyRegistrationResponse{Options: options}, nil
+}
+
+func (s *passkeyService) FinishRegistration(
+	ctx context.Context,
+	userId string,
+	req dto.PasskeyRegisterRequest,
+) (dto.PasskeyResponse, error) {
+	sessionData, err := s.consumeCeremony(ctx, passkeyRegistrationKeyPrefix+userId)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	user, err := s.loadUser(ctx, userId)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, sessionData, parsed)
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = passkeyDefaultCredentialName
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	created, err := s.webAuthnCredentialRepository.Create(ctx, s.db, entities.WebAuthnCredential{
+		UserID:          user.user.ID,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		Transports:      transports,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		Name:            name,
+	})
+	if err != nil {
+		return dto.PasskeyResponse{}, err
+	}
+
+	return toPasskeyResponse(created), nil
+}
+
+func (s *passkeyService) ListPasskeys(ctx context.Context, userId string) ([]dto.PasskeyResponse, error) {
+	credentials, err := s.webAuthnCredentialRepository.FindByUserID(ctx, s.db, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.PasskeyResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		response = append(response, toPasskeyResponse(credential))
+	}
+
+	return response, nil
+}
+
+func (s *passkeyService) DeletePasskey(ctx context.Context, userId string, id string) error {
+	err := s.webAuthnCredentialRepository.Delete(ctx, s.db, userId, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrPasskeyNotFound
+	}
+
+	return err
+}
+
+// BeginLogin starts a discoverable assertion, whatever email is given. The
+// browser offers any passkey of this site, and the options never list
+// credentials, so the response does not tell which addresses are registered
+// or have passkeys.
+func (s *passkeyService) BeginLogin(ctx context.Context, _ dto.PasskeyLoginBeginRequest) (dto.PasskeyLoginBeginResponse, error) {
+	options, sessionData, err := s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return dto.PasskeyLoginBeginResponse{}, err
+	}
+
+	sessionId, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return dto.PasskeyLoginBeginResponse{}, err
+	}
+
+	if err := s.saveCeremony(ctx, passkeyLoginKeyPrefix+sessionId, sessionData); err != nil {
+		return dto.PasskeyLoginBeginResponse{}, err
+	}
+
+	return dto.PasskeyLoginBeginResponse{
+		SessionID: sessionId,
+		Options:   options,
+	}, nil
+}
+
+// FinishLogin verifies the assertion and signs the user in with the same
+// tokens as a password login. The authenticator verified the user, so no
+// second factor is asked for.
+func (s *passkeyService) FinishLogin(
+	ctx context.Context,
+	req dto.PasskeyLoginFinishRequest,
+	session dto.SessionInfo,
+) (dto.TokenResponse, error) {
+	sessionData, err := s.consumeCeremony(ctx, passkeyLoginKeyPrefix+req.SessionID)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	found, credential, err := s.webAuthn.ValidatePasskeyLogin(func(_, userHandle []byte) (webauthn.User, error) {
+		user, err := s.loadUserByHandle(ctx, userHandle)
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}, sessionData, parsed)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+	user := found.(*webAuthnUser)
+
+	if credential.Authenticator.CloneWarning {
+		return dto.TokenResponse{}, dto.ErrPasskeyCloneDetected
+	}
+
+	var response dto.TokenResponse
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.webAuthnCredentialRepository.UpdateAfterLogin(
+			ctx,
+			tx,
+			credential.ID,
+			int64(credential.Authenticator.SignCount),
+			credential.Flags.BackupState,
+		); err != nil {
+			return err
+		}
+
+		response, err = s.tokenIssuer.issueTokens(ctx, tx, user.user, session)
+		return err
+	})
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (s *passkeyService) saveCeremony(ctx context.Context, key string, sessionData *webauthn.SessionData) error {
+	value, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	return s.store.Set(ctx, key, string(value), passkeyCeremonyExpiry)
+}
+
+// consumeCeremony returns the session data of a started ceremony, which can
+// only be finished once
+func (s *passkeyService) consumeCeremony(ctx context.Context, key string) (webauthn.SessionData, error) {
+	value, found, err := s.store.Get(ctx, key)
+	if err != nil {
+		return webauthn.SessionData{}, err
+	}
+	if !found {
+		return webauthn.SessionData{}, dto.ErrPasskeyCeremonyInvalid
+	}
+
+	if err := s.store.Delete(ctx, key); err != nil {
+		return webauthn.SessionData{}, err
+	}
+
+	var sessionData webauthn.SessionData
+	if err := json.Unmarshal([]byte(value), &sessionData); err != nil {
+		return webauthn.SessionData{}, dto.ErrPasskeyCeremonyInvalid
+	}
+
+	return sessionData, nil
+}
+
+func (s *passkeyService) loadUser(ctx context.Context, userId string) (*webAuthnUser, error) {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return nil, userDto.ErrUserNotFound
+	}
+
+	credentials, err := s.webAuthnCredentialRepository.FindByUserID(ctx, s.db, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// loadUserByHandle resolves the user handle, which is the raw user ID
+func (s *passkeyService) loadUserByHandle(ctx context.Context, userHandle []byte) (*webAuthnUser, error) {
+	userId, err := uuid.FromBytes(userHandle)
+	if err != nil {
+		return nil, userDto.ErrUserNotFound
+	}
+
+	return s.loadUser(ctx, userId.String())
+}
+
+// webAuthnUser adapts a user and their credentials to webauthn.User
+type webAuthnUser struct {
+	user        entities.User
+	credentials []entities.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return u.user.ID[:]
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.Name
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0, len(c.Transports))
+		for _, transport := range c.Transports {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: uint32(c.SignCount),
+			},
+		})
+	}
+
+	return credentials
+}
+
+func toPasskeyResponse(credential entities.WebAuthnCredential) dto.PasskeyResponse {
+	return dto.PasskeyResponse{
+		ID:             credential.ID.String(),
+		Name:           credential.Name,
+		Transports:     credential.Transports,
+		BackupEligible: credential.BackupEligible,
+		BackupState:    credential.BackupState,
+		CreatedAt:      credential.CreatedAt,
+		LastUsedAt:     credential.LastUsedAt,
+	}
+}
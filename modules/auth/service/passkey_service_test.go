@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	"blog/pkg/cache"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeWebAuthnCredentialRepository struct {
+	authRepo.WebAuthnCredentialRepository
+
+	credentials []entities.WebAuthnCredential
+}
+
+func (r *fakeWebAuthnCredentialRepository) FindByUserID(
+	_ context.Context,
+	_ *gorm.DB,
+	userID string,
+) ([]entities.WebAuthnCredential, error) {
+	var found []entities.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID.String() == userID {
+			found = append(found, credential)
+		}
+	}
+	return found, nil
+}
+
+// loginOptionsShape returns the login options as JSON with the random
+// challenge left out, so two responses compare by their shape alone
+func loginOptionsShape(t *testing.T, response dto.PasskeyLoginBeginResponse) map[string]any {
+	t.Helper()
+
+	data, err := json.Marshal(response.Options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var shape map[string]any
+	if err := json.Unmarshal(data, &shape); err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, ok := shape["publicKey"].(map[string]any)
+	if !ok {
+		t.Fatalf("options without publicKey: %s", data)
+	}
+	if _, ok := publicKey["challenge"]; !ok {
+		t.Fatalf("options without a challenge: %s", data)
+	}
+	delete(publicKey, "challenge")
+
+	return shape
+}
+
+func TestPasskeyBeginLoginHidesRegisteredAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	user := entities.User{ID: uuid.New(), Name: "Test", Email: "test@example.com", IsVerified: true}
+	credentials := &fakeWebAuthnCredentialRepository{credentials: []entities.WebAuthnCredential{{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		CredentialID: []byte("credential-id"),
+		PublicKey:    []byte("public-key"),
+		Transports:   []string{"internal"},
+	}}}
+
+	webAuthn, err := NewWebAuthn(&config.WebAuthnConfig{
+		RPID:          "localhost",
+		RPDisplayName: "Test",
+		RPOrigins:     []string{"http://localhost:3000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwtService := newTestJWTService(t)
+	service := NewPasskeyService(
+		webAuthn,
+		credentials,
+		newFakeUserRepository(user),
+		nil,
+		jwtService,
+		nil,
+		cache.NewMemoryStore(),
+		newTestDB(t),
+	)
+
+	registered, err := service.BeginLogin(ctx, dto.PasskeyLoginBeginRequest{Email: user.Email})
+	if err != nil {
+		t.Fatalf("BeginLogin for a registered email: %v", err)
+	}
+
+	unknown, err := service.BeginLogin(ctx, dto.PasskeyLoginBeginRequest{Email: "nobody@example.com"})
+	if err != nil {
+		t.Fatalf("BeginLogin for an unknown email: %v", err)
+	}
+
+	registeredShape := loginOptionsShape(t, registered)
+	if _, ok := registeredShape["publicKey"].(map[string]any)["allowCredentials"]; ok {
+		t.Errorf("options for a registered email list credentials: %v", registeredShape)
+	}
+
+	if unknownShape := loginOptionsShape(t, unknown); !reflect.DeepEqual(registeredShape, unknownShape) {
+		t.Errorf("options differ between emails:\nregistered %v\nunknown    %v", registeredShape, unknownShape)
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	"blog/pkg/helpers"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// personalInfoMinLength keeps short names such as "Al" from rejecting half
+// of all passwords
+const personalInfoMinLength = 3
+
+// NewPasswordHasher builds the hasher new passwords are stored with
+func NewPasswordHasher(cfg *config.PasswordPolicyConfig) (helpers.PasswordHasher, error) {
+	switch cfg.HashAlgorithm {
+	case "bcrypt":
+		return helpers.NewBcryptHasher(cfg.HashCost)
+	case "argon2id":
+		if cfg.Argon2Memory < 8*cfg.Argon2Parallelism || cfg.Argon2Iterations < 1 ||
+			cfg.Argon2Parallelism < 1 || cfg.Argon2Parallelism > 255 {
+			return nil, errors.New("argon2id needs at least 1 iteration, 1 to 255 threads and 8 KiB of memory per thread")
+		}
+
+		hasher := helpers.DefaultArgon2idHasher()
+		hasher.Memory = uint32(cfg.Argon2Memory)
+		hasher.Iterations = uint32(cfg.Argon2Iterations)
+		hasher.Parallelism = uint8(cfg.Argon2Parallelism)
+		return hasher, nil
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.HashAlgorithm)
+	}
+}
+
+// PasswordPolicyService decides whether a new password is acceptable and
+// remembers the ones a user has set.
+type PasswordPolicyService interface {
+	// Validate checks password against the policy. Reuse is only checked when
+	// user already exists.
+	Validate(ctx context.Context, tx *gorm.DB, user entities.User, password string) error
+	// Remember stores the hash of a newly set password in the user's history
+	Remember(ctx context.Context, tx *gorm.DB, userId string, passwordHash string) error
+}
+
+type passwordPolicyService struct {
+	config                    *config.PasswordPolicyConfig
+	passwordHistoryRepository authRepo.PasswordHistoryRepository
+	breachedChecker           BreachedPasswordChecker
+}
+
+func NewPasswordPolicyService(
+	cfg *config.PasswordPolicyConfig,
+	passwordHistoryRepo authRepo.PasswordHistoryRepository,
+	breachedChecker BreachedPasswordChecker,
+) PasswordPolicyService {
+	return &passwordPolicyService{
+		config:                    cfg,
+		passwordHistoryRepository: passwordHistoryRepo,
+		breachedChecker:           breachedChecker,
+	}
+}
+
+func (s *passwordPolicyService) Validate(ctx context.Context, tx *gorm.DB, user entities.User, password string) error {
+	if utf8.RuneCountInString(password) < s.config.MinLength {
+		return fmt.Errorf("%w, use at least %d characters", dto.ErrPasswordTooShort, s.config.MinLength)
+	}
+
+	if len(password) > s.config.MaxLength {
+		return fmt.Errorf("%w, use at most %d bytes", dto.ErrPasswordTooLong, s.config.MaxLength)
+	}
+
+	if err := s.checkCharacterClasses(password); err != nil {
+		return err
+	}
+
+	if containsPersonalInfo(password, user) {
+		return dto.ErrPasswordPersonalInfo
+	}
+
+	breached, err := s.breachedChecker.IsBreached(password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		return dto.ErrPasswordBreached
+	}
+
+	if user.ID == uuid.Nil {
+		return nil
+	}
+
+	return s.checkReuse(ctx, tx, user, password)
+}
+
+func (s *passwordPolicyService) Remember(ctx context.Context, tx *gorm.DB, userId string, passwordHash string) error {
+	if s.config.HistorySize <= 0 {
+		return nil
+	}
+
+	id, err := uuid.Parse(userId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.passwordHistoryRepository.Create(ctx, tx, entities.PasswordHistory{
+		UserID:       id,
+		PasswordHash: passwordHash,
+	}); err != nil {
+		return err
+	}
+
+	return s.passwordHistoryRepository.Prune(ctx, tx, userId, s.config.HistorySize)
+}
+
+func (s *passwordPolicyService) checkCharacterClasses(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	classes := []struct {
+		required bool
+		present  bool
+		name     string
+	}{
+		{s.config.RequireUpper, hasUpper, "an uppercase letter"},
+		{s.config.RequireLower, hasLower, "a lowercase letter"},
+		{s.config.RequireDigit, hasDigit, "a digit"},
+		{s.config.RequireSymbol, hasSymbol, "a symbol"},
+	}
+
+	for _, class := range classes {
+		if class.required && !class.present {
+			return fmt.Errorf("%w, add %s", dto.ErrPasswordMissingClass, class.name)
+		}
+	}
+
+	return nil
+}
+
+// checkReuse compares password with the current one and the remembered ones.
+// Users from before the history existed only have the current one.
+func (s *passwordPolicyService) checkReuse(ctx context.Context, tx *gorm.DB, user entities.User, password string) error {
+	hashes := []string{user.Password}
+
+	if s.config.HistorySize > 0 {
+		histories, err := s.passwordHistoryRepository.FindRecentByUserID(ctx, tx, user.ID.String(), s.config.HistorySize)
+		if err != nil {
+			return err
+		}
+		for _, history := range histories {
+			hashes = append(hashes, history.PasswordHash)
+		}
+	}
+
+	for _, hash := range hashes {
+		if hash == "" {
+			continue
+		}
+		if matched, _, _ := helpers.CheckPassword(hash, []byte(password)); matched {
+			return dto.ErrPasswordRecentlyUsed
+		}
+	}
+
+	return nil
+}
+
+func containsPersonalInfo(password string, user entities.User) bool {
+	password = strings.ToLower(password)
+
+	var parts []string
+	if local, _, found := strings.Cut(user.Email, "@"); found {
+		parts = append(parts, local)
+	}
+	parts = append(parts, strings.FieldsFunc(user.Name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})...)
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= personalInfoMinLength && strings.Contains(password, strings.ToLower(part)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userId string, currentSessionId string) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userId string, sessionId string) error
+	RevokeOtherSessions(ctx context.Context, userId string, req dto.RevokeOtherSessionsRequest) error
+}
+
+type sessionService struct {
+	refreshTokenRepository authRepo.RefreshTokenRepository
+	jwtService             JWTService
+	revocationService      TokenRevocationService
+	db                     *gorm.DB
+}
+
+func NewSessionService(
+	refreshTokenRepo authRepo.RefreshTokenRepository,
+	jwtService JWTService,
+	revocationService TokenRevocationService,
+	db *gorm.DB,
+) SessionService {
+	return &sessionService{
+		refreshTokenRepository: refreshTokenRepo,
+		jwtService:             jwtService,
+		revocationService:      revocationService,
+		db:                     db,
+	}
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userId string, currentSessionId string) ([]dto.SessionResponse, error) {
+	refreshTokens, err := s.refreshTokenRepository.FindActiveByUserID(ctx, s.db, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]dto.SessionResponse, 0, len(refreshTokens))
+	for _, refreshToken := range refreshTokens {
+		sessions = append(sessions, dto.SessionResponse{
+			ID:          refreshToken.FamilyID.String(),
+			DeviceLabel: refreshToken.DeviceLabel,
+			UserAgent:   refreshToken.UserAgent,
+			IPAddress:   refreshToken.IPAddress,
+			CreatedAt:   refreshToken.SessionStartedAt,
+			LastUsedAt:  refreshToken.LastUsedAt,
+			ExpiresAt:   refreshToken.ExpiresAt,
+			IsCurrent:   refreshToken.FamilyID.String() == currentSessionId,
+		})
+	}
+
+	return sessions, nil
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userId string, sessionId string) error {
+	if _, err := uuid.Parse(sessionId); err != nil {
+		return dto.ErrSessionNotFound
+	}
+
+	err := s.refreshTokenRepository.RevokeSession(ctx, s.db, userId, sessionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.revocationService.RevokeSession(ctx, sessionId)
+}
+
+func (s *sessionService) RevokeOtherSessions(
+	ctx context.Context,
+	userId string,
+	req dto.RevokeOtherSessionsRequest,
+) error {
+	var revoked []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := s.refreshTokenRepository.FindByToken(ctx, tx, s.jwtService.HashRefreshToken(req.RefreshToken))
+		if err != nil || current.UserID.String() != userId || current.RevokedAt != nil {
+			return dto.ErrSessionNotFound
+		}
+
+		sessions, err := s.refreshTokenRepository.FindActiveByUserID(ctx, tx, userId)
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			if session.FamilyID != current.FamilyID {
+				revoked = append(revoked, session.FamilyID.String())
+			}
+		}
+
+		return s.refreshTokenRepository.RevokeOtherSessions(ctx, tx, userId, current.FamilyID.String())
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sessionId := range revoked {
+		if err := s.revocationService.RevokeSession(ctx, sessionId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
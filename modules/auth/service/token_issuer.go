@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tokenIssuer starts sessions. Every way of signing in ends here, so they all
+// hand out the same tokens and honor two-factor authentication.
+type tokenIssuer struct {
+	refreshTokenRepository authRepo.RefreshTokenRepository
+	jwtService             JWTService
+	twoFactorService       TwoFactorService
+	db                     *gorm.DB
+}
+
+func newTokenIssuer(
+	refreshTokenRepo authRepo.RefreshTokenRepository,
+	jwtService JWTService,
+	twoFactorService TwoFactorService,
+	db *gorm.DB,
+) *tokenIssuer {
+	return &tokenIssuer{
+		refreshTokenRepository: refreshTokenRepo,
+		jwtService:             jwtService,
+		twoFactorService:       twoFactorService,
+		db:                     db,
+	}
+}
+
+// login issues the tokens of an authenticated user, or only a challenge token
+// when the user still has to present a second factor
+func (t *tokenIssuer) login(ctx context.Context, user entities.User, session dto.SessionInfo) (dto.LoginResponse, error) {
+	twoFactorEnabled, err := t.twoFactorService.IsEnabled(ctx, user.ID.String())
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	if twoFactorEnabled {
+		challengeToken, err := t.twoFactorService.IssueChallenge(ctx, user.ID.String())
+		if err != nil {
+			return dto.LoginResponse{}, err
+		}
+
+		return dto.LoginResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		}, nil
+	}
+
+	tokens, err := t.issueTokens(ctx, t.db, user, session)
+	if err != nil {
+		return dto.LoginResponse{}, err
+	}
+
+	return dto.LoginResponse{TokenResponse: &tokens}, nil
+}
+
+// issueTokens starts a new session (refresh token family) for the user and
+// returns the access and refresh tokens handed to the client
+func (t *tokenIssuer) issueTokens(
+	ctx context.Context,
+	tx *gorm.DB,
+	user entities.User,
+	session dto.SessionInfo,
+) (dto.TokenResponse, error) {
+	familyID := uuid.New()
+	accessToken := t.jwtService.GenerateAccessToken(user.ID.String(), user.Role, familyID.String())
+	refreshTokenString, expiresAt := t.jwtService.GenerateRefreshToken()
+
+	now := time.Now()
+	refreshToken := entities.RefreshToken{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		FamilyID:         familyID,
+		TokenHash:        t.jwtService.HashRefreshToken(refreshTokenString),
+		ExpiresAt:        expiresAt,
+		UserAgent:        session.UserAgent,
+		IPAddress:        session.IPAddress,
+		DeviceLabel:      session.DeviceLabel,
+		SessionStartedAt: now,
+		LastUsedAt:       now,
+	}
+
+	if _, err := t.refreshTokenRepository.Create(ctx, tx, refreshToken); err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	return dto.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshTokenString,
+		Role:         user.Role,
+	}, nil
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/pkg/cache"
+	"github.com/google/uuid"
+)
+
+func newShortLivedJWTService(t *testing.T, expiry time.Duration, leeway time.Duration) JWTService {
+	t.Helper()
+
+	cfg, err := config.NewJWTConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Secret = "test"
+	cfg.RefreshSecret = "test"
+	cfg.AccessExpiry = expiry
+	cfg.Leeway = leeway
+
+	jwtService, err := NewJWTService(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtService
+}
+
+// assertRevokedUntil checks the token is rejected while ValidateToken still
+// accepts it past exp, and only drops out once the leeway is over
+func assertRevokedUntil(t *testing.T, jwtService JWTService, revocationService TokenRevocationService, token string) {
+	t.Helper()
+	ctx := context.Background()
+
+	claims, err := jwtService.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	time.Sleep(time.Until(claims.ExpiresAt.Time.Add(jwtService.Leeway() / 2)))
+
+	if _, err := jwtService.ValidateToken(token); err != nil {
+		t.Fatalf("ValidateToken within the leeway: %v", err)
+	}
+	revoked, err := revocationService.IsRevoked(ctx, claims)
+	if err != nil || !revoked {
+		t.Fatalf("IsRevoked past exp within the leeway = %v, %v", revoked, err)
+	}
+
+	time.Sleep(time.Until(claims.ExpiresAt.Time.Add(jwtService.Leeway())))
+
+	if _, err := jwtService.ValidateToken(token); err == nil {
+		t.Fatal("ValidateToken accepts the token after exp + leeway")
+	}
+}
+
+func TestRevokedTokenStaysRejectedThroughLeeway(t *testing.T) {
+	jwtService := newShortLivedJWTService(t, 100*time.Millisecond, 200*time.Millisecond)
+	revocationService := NewTokenRevocationService(cache.NewMemoryStore(), jwtService)
+
+	token := jwtService.GenerateAccessToken(uuid.NewString(), "user", uuid.NewString())
+	if err := revocationService.RevokeToken(context.Background(), token); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+
+	assertRevokedUntil(t, jwtService, revocationService, token)
+}
+
+func TestRevokedUserTokensStayRejectedThroughLeeway(t *testing.T) {
+	jwtService := newShortLivedJWTService(t, 100*time.Millisecond, 200*time.Millisecond)
+	revocationService := NewTokenRevocationService(cache.NewMemoryStore(), jwtService)
+
+	userId := uuid.NewString()
+	token := jwtService.GenerateAccessToken(userId, "user", uuid.NewString())
+	if err := revocationService.RevokeUserTokens(context.Background(), userId); err != nil {
+		t.Fatalf("RevokeUserTokens: %v", err)
+	}
+
+	assertRevokedUntil(t, jwtService, revocationService, token)
+}
+
+func TestRevokedSessionRejectsOnlyItsTokens(t *testing.T) {
+	ctx := context.Background()
+	jwtService := newTestJWTService(t)
+	revocationService := newTestRevocationService(t, jwtService)
+
+	userId := uuid.NewString()
+	revokedSession, otherSession := uuid.NewString(), uuid.NewString()
+	if err := revocationService.RevokeSession(ctx, revokedSession); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+
+	for sessionId, want := range map[string]bool{revokedSession: true, otherSession: false} {
+		claims, err := jwtService.ValidateToken(jwtService.GenerateAccessToken(userId, "user", sessionId))
+		if err != nil {
+			t.Fatalf("ValidateToken: %v", err)
+		}
+
+		revoked, err := revocationService.IsRevoked(ctx, claims)
+		if err != nil || revoked != want {
+			t.Errorf("IsRevoked for session %s = %v, %v, want %v", sessionId, revoked, err, want)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	userDto "blog/modules/user/dto"
+	"blog/modules/user/repository"
+	"blog/pkg/cache"
+	"blog/pkg/constants"
+	"blog/pkg/helpers"
+	"blog/pkg/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengeExpiry   = time.Minute * 5
+	twoFactorMaxAttempts       = 5
+	twoFactorAttemptsKeyPrefix = "2fa:attempts:"
+	twoFactorSkewSteps         = 1
+	recoveryCodeCount          = 10
+)
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, userId string) (dto.TwoFactorEnrollResponse, error)
+	Confirm(ctx context.Context, userId string, req dto.TwoFactorCodeRequest) (dto.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userId string, req dto.TwoFactorCodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userId string, req dto.TwoFactorCodeRequest) (dto.RecoveryCodesResponse, error)
+	IsEnabled(ctx context.Context, userId string) (bool, error)
+	IssueChallenge(ctx context.Context, userId string) (string, error)
+	VerifyChallenge(ctx context.Context, challengeToken string, code string) (string, error)
+}
+
+type twoFactorService struct {
+	twoFactorRepository    authRepo.TwoFactorRepository
+	recoveryCodeRepository authRepo.RecoveryCodeRepository
+	userRepository         repository.UserRepository
+	jwtService             JWTService
+	revocationService      TokenRevocationService
+	store                  cache.Store
+	encryptionKey          []byte
+	db                     *gorm.DB
+}
+
+func NewTwoFactorService(
+	twoFactorRepo authRepo.TwoFactorRepository,
+	recoveryCodeRepo authRepo.RecoveryCodeRepository,
+	userRepo repository.UserRepository,
+	jwtService JWTService,
+	revocationService TokenRevocationService,
+	store cache.Store,
+	cfg *config.TwoFactorConfig,
+	db *gorm.DB,
+) TwoFactorService {
+	return &twoFactorService{
+		twoFactorRepository:    twoFactorRepo,
+		recoveryCodeRepository: recoveryCodeRepo,
+		userRepository:         userRepo,
+		jwtService:             jwtService,
+		revocationService:      revocationService,
+		store:                  store,
+		encryptionKey:          cfg.EncryptionKey,
+		db:                     db,
+	}
+}
+
+// Enroll creates a new secret for the user. It is not enforced until Confirm
+// proves the authenticator app was set up; enrolling again before that
+// replaces the pending secret.
+func (s *twoFactorService) Enroll(ctx context.Context, userId string) (dto.TwoFactorEnrollResponse, error) {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, userDto.ErrUserNotFound
+	}
+
+	credential, err := s.twoFactorRepository.FindByUserID(ctx, s.db, userId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	if credential.ConfirmedAt != nil {
+		return dto.TwoFactorEnrollResponse{}, dto.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	encrypted, err := utils.AESEncrypt(s.encryptionKey, secret)
+	if err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	if credential.ID == uuid.Nil {
+		credential.ID = uuid.New()
+		credential.UserID = user.ID
+	}
+	credential.SecretEncrypted = encrypted
+	credential.LastUsedStep = 0
+
+	if _, err := s.twoFactorRepository.Save(ctx, s.db, credential); err != nil {
+		return dto.TwoFactorEnrollResponse{}, err
+	}
+
+	return dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: helpers.TOTPURI(totpIssuer(), user.Email, secret),
+	}, nil
+}
+
+// Confirm enables two-factor login once the first code checks out and hands
+// out the recovery codes, which are never shown again
+func (s *twoFactorService) Confirm(
+	ctx context.Context,
+	userId string,
+	req dto.TwoFactorCodeRequest,
+) (dto.RecoveryCodesResponse, error) {
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		credential, err := s.twoFactorRepository.FindByUserID(ctx, tx, userId)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrTwoFactorNotEnrolled
+		}
+		if err != nil {
+			return err
+		}
+
+		if credential.ConfirmedAt != nil {
+			return dto.ErrTwoFactorAlreadyEnabled
+		}
+
+		if err := s.verifyTOTP(ctx, tx, &credential, req.Code); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		credential.ConfirmedAt = &now
+		if _, err := s.twoFactorRepository.Save(ctx, tx, credential); err != nil {
+			return err
+		}
+
+		codes, err = s.replaceRecoveryCodes(ctx, tx, credential.UserID)
+		return err
+	})
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor off after checking a code against the same
+// per-user attempt limit as login, so a stolen access token cannot be used
+// to guess its way past the second factor
+func (s *twoFactorService) Disable(ctx context.Context, userId string, req dto.TwoFactorCodeRequest) error {
+	if err := s.reserveAttempt(ctx, userId); err != nil {
+		return err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(ctx, tx, userId, req.Code); err != nil {
+			return err
+		}
+
+		if err := s.recoveryCodeRepository.DeleteByUserID(ctx, tx, userId); err != nil {
+			return err
+		}
+
+		return s.twoFactorRepository.DeleteByUserID(ctx, tx, userId)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.store.Delete(ctx, twoFactorAttemptsKeyPrefix+userId)
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(
+	ctx context.Context,
+	userId string,
+	req dto.TwoFactorCodeRequest,
+) (dto.RecoveryCodesResponse, error) {
+	if err := s.reserveAttempt(ctx, userId); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	var codes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(ctx, tx, userId, req.Code); err != nil {
+			return err
+		}
+
+		id, err := uuid.Parse(userId)
+		if err != nil {
+			return userDto.ErrUserNotFound
+		}
+
+		codes, err = s.replaceRecoveryCodes(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	if err := s.store.Delete(ctx, twoFactorAttemptsKeyPrefix+userId); err != nil {
+		return dto.RecoveryCodesResponse{}, err
+	}
+
+	return dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+func (s *twoFactorService) IsEnabled(ctx context.Context, userId string) (bool, error) {
+	credential, err := s.twoFactorRepository.FindByUserID(ctx, s.db, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return credential.ConfirmedAt != nil, nil
+}
+
+// IssueChallenge returns the token a client exchanges, together with a code,
+// for the real tokens once the password has been checked
+func (s *twoFactorService) IssueChallenge(ctx context.Context, userId string) (string, error) {
+	return s.jwtService.GenerateChallengeToken(userId, constants.ENUM_TOKEN_PURPOSE_TWO_FACTOR, twoFactorChallengeExpiry)
+}
+
+// VerifyChallenge checks the code against the user of a challenge token and
+// returns that user. A challenge is spent on success and after too many
+// wrong codes. Attempts are counted per user, so logging in again for a
+// fresh challenge does not buy more guesses.
+func (s *twoFactorService) VerifyChallenge(ctx context.Context, challengeToken string, code string) (string, error) {
+	claims, err := s.jwtService.ValidateToken(challengeToken)
+	if err != nil || claims.Purpose != constants.ENUM_TOKEN_PURPOSE_TWO_FACTOR || claims.ID == "" {
+		return "", dto.ErrChallengeTokenInvalid
+	}
+
+	revoked, err := s.revocationService.IsRevoked(ctx, claims)
+	if err != nil {
+		return "", err
+	}
+	if revoked {
+		return "", dto.ErrChallengeTokenInvalid
+	}
+
+	if err := s.reserveAttempt(ctx, claims.UserID); err != nil {
+		if errors.Is(err, dto.ErrTooManyTwoFactorAttempts) {
+			if err := s.revocationService.RevokeToken(ctx, challengeToken); err != nil {
+				return "", err
+			}
+		}
+		return "", err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.verify(ctx, tx, claims.UserID, code)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.revocationService.RevokeToken(ctx, challengeToken); err != nil {
+		return "", err
+	}
+
+	if err := s.store.Delete(ctx, twoFactorAttemptsKeyPrefix+claims.UserID); err != nil {
+		return "", err
+	}
+
+	return claims.UserID, nil
+}
+
+// reserveAttempt counts a code check against the per-user limit before the
+// code is looked at, so parallel guesses count too. The counter runs for one
+// challenge lifetime from the first attempt and is shared by every check.
+func (s *twoFactorService) reserveAttempt(ctx context.Context, userId string) error {
+	attempts, err := s.store.Incr(ctx, twoFactorAttemptsKeyPrefix+userId, twoFactorChallengeExpiry)
+	if err != nil {
+		return err
+	}
+	if attempts > twoFactorMaxAttempts {
+		return dto.ErrTooManyTwoFactorAttempts
+	}
+
+	return nil
+}
+
+// verify accepts either a current TOTP code or an unused recovery code
+func (s *twoFactorService) verify(ctx context.Context, tx *gorm.DB, userId string, code string) error {
+	credential, err := s.twoFactorRepository.FindByUserID(ctx, tx, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return err
+	}
+
+	if credential.ConfirmedAt == nil {
+		return dto.ErrTwoFactorNotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == helpers.TOTPDigits {
+		return s.verifyTOTP(ctx, tx, &credential, code)
+	}
+
+	err = s.recoveryCodeRepository.Consume(ctx, tx, userId, helpers.HashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrInvalidTwoFactorCode
+	}
+
+	return err
+}
+
+// verifyTOTP spends the time step of a valid code and records it on the
+// credential, so a later Save does not hand the step back
+func (s *twoFactorService) verifyTOTP(
+	ctx context.Context,
+	tx *gorm.DB,
+	credential *entities.TwoFactorCredential,
+	code string,
+) error {
+	secret, err := utils.AESDecrypt(s.encryptionKey, credential.SecretEncrypted)
+	if err != nil {
+		return err
+	}
+
+	step, ok := helpers.ValidateTOTP(secret, strings.TrimSpace(code), time.Now(), twoFactorSkewSteps)
+	if !ok {
+		return dto.ErrInvalidTwoFactorCode
+	}
+
+	err = s.twoFactorRepository.UseStep(ctx, tx, credential.ID.String(), step)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrInvalidTwoFactorCode
+	}
+	if err != nil {
+		return err
+	}
+
+	credential.LastUsedStep = step
+	return nil
+}
+
+func (s *twoFactorService) replaceRecoveryCodes(ctx context.Context, tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]entities.RecoveryCode, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		records = append(records, entities.RecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: helpers.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := s.recoveryCodeRepository.Replace(ctx, tx, userID.String(), records); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code such as "k3q7m-x2p9d"
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func totpIssuer() string {
+	if issuer := os.Getenv("APP_NAME"); issuer != "" {
+		return issuer
+	}
+	return "Template"
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blog/config"
+	"blog/database/entities"
+	"blog/modules/auth/dto"
+	"blog/pkg/cache"
+	"blog/pkg/helpers"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeTwoFactorRepository struct {
+	mu          sync.Mutex
+	credentials map[string]entities.TwoFactorCredential
+}
+
+func (r *fakeTwoFactorRepository) FindByUserID(_ context.Context, _ *gorm.DB, userID string) (entities.TwoFactorCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[userID]
+	if !ok {
+		return entities.TwoFactorCredential{}, gorm.ErrRecordNotFound
+	}
+	return credential, nil
+}
+
+func (r *fakeTwoFactorRepository) Save(
+	_ context.Context,
+	_ *gorm.DB,
+	credential entities.TwoFactorCredential,
+) (entities.TwoFactorCredential, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.credentials[credential.UserID.String()] = credential
+	return credential, nil
+}
+
+func (r *fakeTwoFactorRepository) UseStep(_ context.Context, _ *gorm.DB, id string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userID, credential := range r.credentials {
+		if credential.ID.String() == id && credential.LastUsedStep < step {
+			credential.LastUsedStep = step
+			r.credentials[userID] = credential
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeTwoFactorRepository) DeleteByUserID(_ context.Context, _ *gorm.DB, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.credentials, userID)
+	return nil
+}
+
+type fakeRecoveryCodeRepository struct {
+	mu    sync.Mutex
+	codes map[string][]entities.RecoveryCode
+}
+
+func (r *fakeRecoveryCodeRepository) Replace(_ context.Context, _ *gorm.DB, userID string, codes []entities.RecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.codes[userID] = codes
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepository) Consume(_ context.Context, _ *gorm.DB, userID string, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, code := range r.codes[userID] {
+		if code.CodeHash == codeHash && code.UsedAt == nil {
+			now := time.Now()
+			r.codes[userID][i].UsedAt = &now
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeRecoveryCodeRepository) DeleteByUserID(_ context.Context, _ *gorm.DB, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.codes, userID)
+	return nil
+}
+
+type twoFactorFixture struct {
+	service     TwoFactorService
+	credentials *fakeTwoFactorRepository
+	user        entities.User
+}
+
+func newTwoFactorFixture(t *testing.T) twoFactorFixture {
+	t.Helper()
+
+	user := entities.User{ID: uuid.New(), Name: "Test", Email: "test@example.com", IsVerified: true}
+	credentials := &fakeTwoFactorRepository{credentials: make(map[string]entities.TwoFactorCredential)}
+	jwtService := newTestJWTService(t)
+
+	cfg, err := config.NewTwoFactorConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewTwoFactorService(
+		credentials,
+		&fakeRecoveryCodeRepository{codes: make(map[string][]entities.RecoveryCode)},
+		newFakeUserRepository(user),
+		jwtService,
+		newTestRevocationService(t, jwtService),
+		cache.NewMemoryStore(),
+		cfg,
+		newTestDB(t),
+	)
+
+	return twoFactorFixture{service: service, credentials: credentials, user: user}
+}
+
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := helpers.GenerateTOTP(secret, helpers.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestTwoFactorEnrollAndConfirm(t *testing.T) {
+	ctx := context.Background()
+	f := newTwoFactorFixture(t)
+	userId := f.user.ID.String()
+
+	enrollment, err := f.service.Enroll(ctx, userId)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") {
+		t.Errorf("OTPAuthURI = %q", enrollment.OTPAuthURI)
+	}
+
+	stored := f.credentials.credentials[userId]
+	if stored.SecretEncrypted == "" || strings.Contains(stored.SecretEncrypted, enrollment.Secret) {
+		t.Fatal("secret is not stored encrypted")
+	}
+
+	enabled, err := f.service.IsEnabled(ctx, userId)
+	if err != nil || enabled {
+		t.Fatalf("IsEnabled before Confirm = %v, %v", enabled, err)
+	}
+
+	if _, err := f.service.Confirm(ctx, userId, dto.TwoFactorCodeRequest{Code: "000000"}); !errors.Is(err, dto.ErrInvalidTwoFactorCode) {
+		t.Fatalf("Confirm with a wrong code: %v", err)
+	}
+
+	code := currentTOTP(t, enrollment.Secret)
+	codes, err := f.service.Confirm(ctx, userId, dto.TwoFactorCodeRequest{Code: code})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	if len(codes.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("got %d recovery codes, want %d", len(codes.RecoveryCodes), recoveryCodeCount)
+	}
+
+	enabled, err = f.service.IsEnabled(ctx, userId)
+	if err != nil || !enabled {
+		t.Fatalf("IsEnabled after Confirm = %v, %v", enabled, err)
+	}
+
+	// The code that confirmed the enrollment is spent
+	err = f.service.Disable(ctx, userId, dto.TwoFactorCodeRequest{Code: code})
+	if !errors.Is(err, dto.ErrInvalidTwoFactorCode) {
+		t.Fatalf("Disable with a replayed code: %v", err)
+	}
+
+	if err := f.service.Disable(ctx, userId, dto.TwoFactorCodeRequest{Code: codes.RecoveryCodes[0]}); err != nil {
+		t.Fatalf("Disable with a recovery code: %v", err)
+	}
+}
+
+func TestTwoFactorAttemptsSurviveNewChallenges(t *testing.T) {
+	ctx := context.Background()
+	f := newTwoFactorFixture(t)
+	userId := f.user.ID.String()
+
+	enrollment, err := f.service.Enroll(ctx, userId)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	if _, err := f.service.Confirm(ctx, userId, dto.TwoFactorCodeRequest{Code: currentTOTP(t, enrollment.Secret)}); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	for i := range twoFactorMaxAttempts {
+		challenge, err := f.service.IssueChallenge(ctx, userId)
+		if err != nil {
+			t.Fatalf("IssueChallenge: %v", err)
+		}
+		if _, err := f.service.VerifyChallenge(ctx, challenge, "000000"); !errors.Is(err, dto.ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+
+	challenge, err := f.service.IssueChallenge(ctx, userId)
+	if err != nil {
+		t.Fatalf("IssueChallenge: %v", err)
+	}
+	if _, err := f.service.VerifyChallenge(ctx, challenge, "000000"); !errors.Is(err, dto.ErrTooManyTwoFactorAttempts) {
+		t.Fatalf("attempt past the limit on a fresh challenge: %v", err)
+	}
+}
+
+func TestTwoFactorAttemptsLimitConcurrentGuesses(t *testing.T) {
+	ctx := context.Background()
+	f := newTwoFactorFixture(t)
+	userId := f.user.ID.String()
+
+	enrollment, err := f.service.Enroll(ctx, userId)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	code := currentTOTP(t, enrollment.Secret)
+	if _, err := f.service.Confirm(ctx, userId, dto.TwoFactorCodeRequest{Code: code}); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	challenge, err := f.service.IssueChallenge(ctx, userId)
+	if err != nil {
+		t.Fatalf("IssueChallenge: %v", err)
+	}
+
+	// Every guess is a wrong code, whatever the current one is
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+	)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := f.service.VerifyChallenge(ctx, challenge, wrong)
+			switch {
+			case errors.Is(err, dto.ErrInvalidTwoFactorCode):
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			case errors.Is(err, dto.ErrTooManyTwoFactorAttempts), errors.Is(err, dto.ErrChallengeTokenInvalid):
+			default:
+				t.Errorf("VerifyChallenge: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if checked > twoFactorMaxAttempts {
+		t.Errorf("%d concurrent guesses were checked, want at most %d", checked, twoFactorMaxAttempts)
+	}
+}
+
+func TestTwoFactorDisableSharesAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	f := newTwoFactorFixture(t)
+	userId := f.user.ID.String()
+
+	enrollment, err := f.service.Enroll(ctx, userId)
+	if err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	codes, err := f.service.Confirm(ctx, userId, dto.TwoFactorCodeRequest{Code: currentTOTP(t, enrollment.Secret)})
+	if err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+
+	for i := range twoFactorMaxAttempts {
+		_, err := f.service.RegenerateRecoveryCodes(ctx, userId, dto.TwoFactorCodeRequest{Code: "000000"})
+		if !errors.Is(err, dto.ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+
+	err = f.service.Disable(ctx, userId, dto.TwoFactorCodeRequest{Code: codes.RecoveryCodes[0]})
+	if !errors.Is(err, dto.ErrTooManyTwoFactorAttempts) {
+		t.Fatalf("Disable past the limit: %v", err)
+	}
+
+	enabled, err := f.service.IsEnabled(ctx, userId)
+	if err != nil || !enabled {
+		t.Fatalf("IsEnabled after a locked out Disable = %v, %v", enabled, err)
+	}
+}
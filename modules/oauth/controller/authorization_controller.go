@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/oauth/dto"
+	"blog/modules/oauth/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	AuthorizationController interface {
+		Authorize(ctx *gin.Context)
+		Decide(ctx *gin.Context)
+		ListConsents(ctx *gin.Context)
+		RevokeConsent(ctx *gin.Context)
+	}
+
+	authorizationController struct {
+		authorizationService service.AuthorizationService
+	}
+)
+
+func NewAuthorizationController(as service.AuthorizationService) AuthorizationController {
+	return &authorizationController{
+		authorizationService: as,
+	}
+}
+
+func (c *authorizationController) Authorize(ctx *gin.Context) {
+	var req dto.AuthorizeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.authorizationService.Authorize(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_AUTHORIZE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_AUTHORIZE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *authorizationController) Decide(ctx *gin.Context) {
+	var req dto.AuthorizeDecisionRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.authorizationService.Decide(ctx.Request.Context(), userId, req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_AUTHORIZE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_AUTHORIZE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *authorizationController) ListConsents(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.authorizationService.ListConsents(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_CONSENTS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_CONSENTS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *authorizationController) RevokeConsent(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	if err := c.authorizationService.RevokeConsent(ctx.Request.Context(), userId, ctx.Param("client_id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REVOKE_CONSENT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REVOKE_CONSENT, nil)
+	ctx.JSON(http.StatusOK, res)
+}
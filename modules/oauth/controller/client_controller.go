@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/oauth/dto"
+	"blog/modules/oauth/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	ClientController interface {
+		ListClients(ctx *gin.Context)
+		CreateClient(ctx *gin.Context)
+		DeleteClient(ctx *gin.Context)
+	}
+
+	clientController struct {
+		clientService service.ClientService
+	}
+)
+
+func NewClientController(cs service.ClientService) ClientController {
+	return &clientController{
+		clientService: cs,
+	}
+}
+
+func (c *clientController) ListClients(ctx *gin.Context) {
+	result, err := c.clientService.ListClients(ctx.Request.Context())
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_CLIENTS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_CLIENTS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *clientController) CreateClient(ctx *gin.Context) {
+	var req dto.ClientCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.clientService.CreateClient(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_CLIENT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_CLIENT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *clientController) DeleteClient(ctx *gin.Context) {
+	if err := c.clientService.DeleteClient(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_CLIENT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_CLIENT, nil)
+	ctx.JSON(http.StatusOK, res)
+}
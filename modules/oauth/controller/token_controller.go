@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"blog/modules/oauth/dto"
+	"blog/modules/oauth/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenController answers in the plain RFC 6749 format rather than the usual
+// response envelope, since OAuth client libraries parse these bodies
+type (
+	TokenController interface {
+		Token(ctx *gin.Context)
+		Introspect(ctx *gin.Context)
+		Revoke(ctx *gin.Context)
+	}
+
+	tokenController struct {
+		tokenService service.TokenService
+	}
+)
+
+func NewTokenController(ts service.TokenService) TokenController {
+	return &tokenController{
+		tokenService: ts,
+	}
+}
+
+func (c *tokenController) Token(ctx *gin.Context) {
+	var req dto.TokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		writeOAuthError(ctx, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	result, err := c.tokenService.Token(ctx.Request.Context(), clientCredentials(ctx), req)
+	if err != nil {
+		writeOAuthError(ctx, err)
+		return
+	}
+
+	noStore(ctx)
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *tokenController) Introspect(ctx *gin.Context) {
+	var req dto.IntrospectRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		writeOAuthError(ctx, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	result, err := c.tokenService.Introspect(ctx.Request.Context(), clientCredentials(ctx), req)
+	if err != nil {
+		writeOAuthError(ctx, err)
+		return
+	}
+
+	noStore(ctx)
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (c *tokenController) Revoke(ctx *gin.Context) {
+	var req dto.RevokeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		writeOAuthError(ctx, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_REQUEST, err.Error()))
+		return
+	}
+
+	if err := c.tokenService.Revoke(ctx.Request.Context(), clientCredentials(ctx), req); err != nil {
+		writeOAuthError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// clientCredentials reads HTTP Basic authentication, whose parts are form
+// encoded per RFC 6749 section 2.3.1, and falls back to the form fields
+func clientCredentials(ctx *gin.Context) dto.ClientCredentials {
+	if id, secret, ok := ctx.Request.BasicAuth(); ok {
+		clientId, err := url.QueryUnescape(id)
+		if err != nil {
+			clientId = id
+		}
+		clientSecret, err := url.QueryUnescape(secret)
+		if err != nil {
+			clientSecret = secret
+		}
+
+		return dto.ClientCredentials{ClientID: clientId, ClientSecret: clientSecret}
+	}
+
+	return dto.ClientCredentials{
+		ClientID:     ctx.PostForm("client_id"),
+		ClientSecret: ctx.PostForm("client_secret"),
+	}
+}
+
+func writeOAuthError(ctx *gin.Context, err error) {
+	var oauthErr *dto.OAuthError
+	if !errors.As(err, &oauthErr) {
+		oauthErr = dto.NewOAuthError(dto.OAUTH_ERROR_SERVER_ERROR, "")
+	}
+
+	if oauthErr.Status == http.StatusUnauthorized {
+		ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+
+	noStore(ctx)
+	ctx.AbortWithStatusJSON(oauthErr.Status, oauthErr)
+}
+
+func noStore(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+}
@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"errors"
+	"net/http"
+)
+
+const (
+	MESSAGE_FAILED_AUTHORIZE       = "failed authorize client"
+	MESSAGE_SUCCESS_AUTHORIZE      = "success authorize client"
+	MESSAGE_FAILED_GET_CONSENTS    = "failed get consents"
+	MESSAGE_SUCCESS_GET_CONSENTS   = "success get consents"
+	MESSAGE_FAILED_REVOKE_CONSENT  = "failed revoke consent"
+	MESSAGE_SUCCESS_REVOKE_CONSENT = "success revoke consent"
+)
+
+var (
+	ErrRedirectURIMismatch = errors.New("redirect uri not registered for this client")
+	ErrConsentNotFound     = errors.New("consent not found")
+)
+
+// Error codes from RFC 6749 section 4.1.2.1 and 5.2
+const (
+	OAUTH_ERROR_INVALID_REQUEST           = "invalid_request"
+	OAUTH_ERROR_INVALID_CLIENT            = "invalid_client"
+	OAUTH_ERROR_INVALID_GRANT             = "invalid_grant"
+	OAUTH_ERROR_INVALID_SCOPE             = "invalid_scope"
+	OAUTH_ERROR_UNAUTHORIZED_CLIENT       = "unauthorized_client"
+	OAUTH_ERROR_UNSUPPORTED_GRANT_TYPE    = "unsupported_grant_type"
+	OAUTH_ERROR_UNSUPPORTED_RESPONSE_TYPE = "unsupported_response_type"
+	OAUTH_ERROR_ACCESS_DENIED             = "access_denied"
+	OAUTH_ERROR_SERVER_ERROR              = "server_error"
+)
+
+// OAuthError is the error body the token, introspection and revocation
+// endpoints answer with, as clients expect it from RFC 6749
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	Status      int    `json:"-"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+func NewOAuthError(code string, description string) *OAuthError {
+	status := http.StatusBadRequest
+	switch code {
+	case OAUTH_ERROR_INVALID_CLIENT:
+		status = http.StatusUnauthorized
+	case OAUTH_ERROR_SERVER_ERROR:
+		status = http.StatusInternalServerError
+	}
+
+	return &OAuthError{
+		Code:        code,
+		Description: description,
+		Status:      status,
+	}
+}
+
+type (
+	AuthorizeRequest struct {
+		ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+		ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+		RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+		Scope               string `form:"scope" json:"scope"`
+		State               string `form:"state" json:"state"`
+		CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+		CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	}
+
+	AuthorizeDecisionRequest struct {
+		AuthorizeRequest
+		Approve bool `form:"approve" json:"approve"`
+	}
+
+	// AuthorizeResponse tells the frontend whether to show the consent screen.
+	// RedirectTo is set once the request is decided and carries the code or
+	// the error back to the client.
+	AuthorizeResponse struct {
+		ClientName      string   `json:"client_name"`
+		Scopes          []string `json:"scopes"`
+		ConsentRequired bool     `json:"consent_required"`
+		RedirectTo      string   `json:"redirect_to,omitempty"`
+	}
+
+	ConsentResponse struct {
+		ClientID   string   `json:"client_id"`
+		ClientName string   `json:"client_name"`
+		Scopes     []string `json:"scopes"`
+		GrantedAt  string   `json:"granted_at"`
+	}
+)
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_CLIENTS   = "failed get oauth clients"
+	MESSAGE_FAILED_CREATE_CLIENT = "failed create oauth client"
+	MESSAGE_FAILED_DELETE_CLIENT = "failed delete oauth client"
+
+	// Success
+	MESSAGE_SUCCESS_GET_CLIENTS   = "success get oauth clients"
+	MESSAGE_SUCCESS_CREATE_CLIENT = "success create oauth client"
+	MESSAGE_SUCCESS_DELETE_CLIENT = "success delete oauth client"
+)
+
+var (
+	ErrClientNotFound          = errors.New("oauth client not found")
+	ErrPublicClientCredentials = errors.New("public clients cannot use the client credentials grant")
+	ErrRedirectURIRequired     = errors.New("authorization code clients need at least one redirect uri")
+)
+
+type (
+	ClientCreateRequest struct {
+		Name         string   `json:"name" binding:"required,min=2,max=100"`
+		RedirectURIs []string `json:"redirect_uris" binding:"omitempty,dive,url"`
+		Scopes       []string `json:"scopes" binding:"required,min=1,dive,required"`
+		GrantTypes   []string `json:"grant_types" binding:"required,min=1,dive,oneof=authorization_code client_credentials"`
+		Public       bool     `json:"public"`
+	}
+
+	// ClientResponse only carries the secret right after the client is
+	// created, it cannot be read back afterwards
+	ClientResponse struct {
+		ID           string   `json:"id"`
+		ClientID     string   `json:"client_id"`
+		ClientSecret string   `json:"client_secret,omitempty"`
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		GrantTypes   []string `json:"grant_types"`
+		Public       bool     `json:"public"`
+	}
+)
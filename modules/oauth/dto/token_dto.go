@@ -0,0 +1,50 @@
+package dto
+
+type (
+	// ClientCredentials are read from HTTP Basic authentication or, failing
+	// that, from the client_id and client_secret form fields
+	ClientCredentials struct {
+		ClientID     string
+		ClientSecret string
+	}
+
+	TokenRequest struct {
+		GrantType    string `form:"grant_type" binding:"required"`
+		Code         string `form:"code"`
+		RedirectURI  string `form:"redirect_uri"`
+		CodeVerifier string `form:"code_verifier"`
+		Scope        string `form:"scope"`
+	}
+
+	TokenResponse struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Scope       string `json:"scope,omitempty"`
+	}
+
+	IntrospectRequest struct {
+		Token         string `form:"token" binding:"required"`
+		TokenTypeHint string `form:"token_type_hint"`
+	}
+
+	// IntrospectionResponse follows RFC 7662, an inactive token only has
+	// active set to false
+	IntrospectionResponse struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Subject   string `json:"sub,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		ExpiresAt int64  `json:"exp,omitempty"`
+		IssuedAt  int64  `json:"iat,omitempty"`
+		NotBefore int64  `json:"nbf,omitempty"`
+		Issuer    string `json:"iss,omitempty"`
+		JTI       string `json:"jti,omitempty"`
+	}
+
+	RevokeRequest struct {
+		Token         string `form:"token" binding:"required"`
+		TokenTypeHint string `form:"token_type_hint"`
+	}
+)
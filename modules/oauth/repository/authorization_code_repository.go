@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, code entities.OAuthAuthorizationCode) (entities.OAuthAuthorizationCode, error)
+	FindByHash(ctx context.Context, tx *gorm.DB, codeHash string) (entities.OAuthAuthorizationCode, error)
+	Consume(ctx context.Context, tx *gorm.DB, id string) error
+}
+
+type authorizationCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthorizationCodeRepository(db *gorm.DB) AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		db: db,
+	}
+}
+
+func (r *authorizationCodeRepository) Create(
+	ctx context.Context,
+	tx *gorm.DB,
+	code entities.OAuthAuthorizationCode,
+) (entities.OAuthAuthorizationCode, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&code).Error; err != nil {
+		return entities.OAuthAuthorizationCode{}, err
+	}
+
+	return code, nil
+}
+
+func (r *authorizationCodeRepository) FindByHash(
+	ctx context.Context,
+	tx *gorm.DB,
+	codeHash string,
+) (entities.OAuthAuthorizationCode, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var code entities.OAuthAuthorizationCode
+	err := tx.WithContext(ctx).
+		Preload("Client").
+		Preload("User").
+		Where("code_hash = ?", codeHash).
+		Take(&code).Error
+	if err != nil {
+		return entities.OAuthAuthorizationCode{}, err
+	}
+
+	return code, nil
+}
+
+// Consume marks the code as used. It only succeeds once, so two concurrent
+// exchanges of the same code cannot both get a token.
+func (r *authorizationCodeRepository) Consume(ctx context.Context, tx *gorm.DB, id string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.OAuthAuthorizationCode{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type ClientRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, client entities.OAuthClient) (entities.OAuthClient, error)
+	FindAll(ctx context.Context, tx *gorm.DB) ([]entities.OAuthClient, error)
+	FindByClientID(ctx context.Context, tx *gorm.DB, clientId string) (entities.OAuthClient, error)
+	Delete(ctx context.Context, tx *gorm.DB, id string) error
+}
+
+type clientRepository struct {
+	db *gorm.DB
+}
+
+func NewClientRepository(db *gorm.DB) ClientRepository {
+	return &clientRepository{
+		db: db,
+	}
+}
+
+func (r *clientRepository) Create(ctx context.Context, tx *gorm.DB, client entities.OAuthClient) (entities.OAuthClient, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&client).Error; err != nil {
+		return entities.OAuthClient{}, err
+	}
+
+	return client, nil
+}
+
+func (r *clientRepository) FindAll(ctx context.Context, tx *gorm.DB) ([]entities.OAuthClient, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var clients []entities.OAuthClient
+	if err := tx.WithContext(ctx).Order("created_at").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (r *clientRepository) FindByClientID(ctx context.Context, tx *gorm.DB, clientId string) (entities.OAuthClient, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var client entities.OAuthClient
+	if err := tx.WithContext(ctx).Where("client_id = ?", clientId).Take(&client).Error; err != nil {
+		return entities.OAuthClient{}, err
+	}
+
+	return client, nil
+}
+
+func (r *clientRepository) Delete(ctx context.Context, tx *gorm.DB, id string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).Where("id = ?", id).Delete(&entities.OAuthClient{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConsentRepository interface {
+	Save(ctx context.Context, tx *gorm.DB, consent entities.OAuthConsent) error
+	FindByUserAndClient(ctx context.Context, tx *gorm.DB, userId string, clientId string) (entities.OAuthConsent, error)
+	FindByUserID(ctx context.Context, tx *gorm.DB, userId string) ([]entities.OAuthConsent, error)
+	CountByUserID(ctx context.Context, tx *gorm.DB, userId string) (int64, error)
+	Delete(ctx context.Context, tx *gorm.DB, userId string, clientId string) error
+}
+
+type consentRepository struct {
+	db *gorm.DB
+}
+
+func NewConsentRepository(db *gorm.DB) ConsentRepository {
+	return &consentRepository{
+		db: db,
+	}
+}
+
+// Save creates the consent or replaces the scopes of the existing one
+func (r *consentRepository) Save(ctx context.Context, tx *gorm.DB, consent entities.OAuthConsent) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+		}).
+		Create(&consent).Error
+}
+
+func (r *consentRepository) FindByUserAndClient(
+	ctx context.Context,
+	tx *gorm.DB,
+	userId string,
+	clientId string,
+) (entities.OAuthConsent, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var consent entities.OAuthConsent
+	err := tx.WithContext(ctx).
+		Where("user_id = ? AND client_id = ?", userId, clientId).
+		Take(&consent).Error
+	if err != nil {
+		return entities.OAuthConsent{}, err
+	}
+
+	return consent, nil
+}
+
+func (r *consentRepository) FindByUserID(ctx context.Context, tx *gorm.DB, userId string) ([]entities.OAuthConsent, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var consents []entities.OAuthConsent
+	err := tx.WithContext(ctx).
+		Preload("Client").
+		Where("user_id = ?", userId).
+		Order("updated_at DESC").
+		Find(&consents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return consents, nil
+}
+
+func (r *consentRepository) CountByUserID(ctx context.Context, tx *gorm.DB, userId string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).Model(&entities.OAuthConsent{}).Where("user_id = ?", userId).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *consentRepository) Delete(ctx context.Context, tx *gorm.DB, userId string, clientId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Where("user_id = ? AND client_id = ?", userId, clientId).
+		Delete(&entities.OAuthConsent{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
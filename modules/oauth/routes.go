@@ -0,0 +1,42 @@
+package oauth
+
+import (
+	"blog/middlewares"
+	authService "blog/modules/auth/service"
+	"blog/modules/oauth/controller"
+	rbacService "blog/modules/rbac/service"
+	"blog/pkg/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func RegisterRoutes(router *gin.Engine, injector *do.Injector) {
+	clientController := do.MustInvoke[controller.ClientController](injector)
+	authorizationController := do.MustInvoke[controller.AuthorizationController](injector)
+	tokenController := do.MustInvoke[controller.TokenController](injector)
+	jwtService := do.MustInvokeNamed[authService.JWTService](injector, constants.JWTService)
+	revocationService := do.MustInvokeNamed[authService.TokenRevocationService](injector, constants.TokenRevocationService)
+	rbacService := do.MustInvokeNamed[rbacService.RBACService](injector, constants.RBACService)
+
+	oauthRoutes := router.Group("/api/v1/oauth")
+	{
+		oauthRoutes.GET("/authorize", middlewares.Authenticate(jwtService, revocationService), authorizationController.Authorize)
+		oauthRoutes.POST("/authorize", middlewares.Authenticate(jwtService, revocationService), authorizationController.Decide)
+		oauthRoutes.POST("/token", tokenController.Token)
+		oauthRoutes.POST("/introspect", tokenController.Introspect)
+		oauthRoutes.POST("/revoke", tokenController.Revoke)
+
+		consentRoutes := oauthRoutes.Group("/consents", middlewares.Authenticate(jwtService, revocationService))
+		{
+			consentRoutes.GET("", authorizationController.ListConsents)
+			consentRoutes.DELETE("/:client_id", authorizationController.RevokeConsent)
+		}
+	}
+
+	adminRoutes := router.Group("/api/v1/admin/oauth", middlewares.Authenticate(jwtService, revocationService))
+	{
+		adminRoutes.GET("/clients", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_OAUTH_CLIENT_MANAGE), clientController.ListClients)
+		adminRoutes.POST("/clients", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_OAUTH_CLIENT_MANAGE), clientController.CreateClient)
+		adminRoutes.DELETE("/clients/:id", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_OAUTH_CLIENT_MANAGE), clientController.DeleteClient)
+	}
+}
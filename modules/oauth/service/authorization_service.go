@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"blog/database/entities"
+	"blog/modules/oauth/dto"
+	"blog/modules/oauth/repository"
+	"blog/pkg/constants"
+	"blog/pkg/helpers"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	authorizationCodeExpiry = time.Minute * 5
+	pkceMethodS256          = "S256"
+)
+
+// AuthorizationService handles the user facing side of the authorization code
+// flow: checking the request, asking for consent and issuing the code
+type AuthorizationService interface {
+	Authorize(ctx context.Context, userId string, req dto.AuthorizeRequest) (dto.AuthorizeResponse, error)
+	Decide(ctx context.Context, userId string, req dto.AuthorizeDecisionRequest) (dto.AuthorizeResponse, error)
+	ListConsents(ctx context.Context, userId string) ([]dto.ConsentResponse, error)
+	RevokeConsent(ctx context.Context, userId string, clientId string) error
+}
+
+type authorizationService struct {
+	clientRepository            repository.ClientRepository
+	authorizationCodeRepository repository.AuthorizationCodeRepository
+	consentRepository           repository.ConsentRepository
+	db                          *gorm.DB
+}
+
+func NewAuthorizationService(
+	clientRepo repository.ClientRepository,
+	authorizationCodeRepo repository.AuthorizationCodeRepository,
+	consentRepo repository.ConsentRepository,
+	db *gorm.DB,
+) AuthorizationService {
+	return &authorizationService{
+		clientRepository:            clientRepo,
+		authorizationCodeRepository: authorizationCodeRepo,
+		consentRepository:           consentRepo,
+		db:                          db,
+	}
+}
+
+// Authorize checks an authorization request. When the user already consented
+// to the requested scopes the code is issued right away, otherwise the
+// response asks for consent.
+func (s *authorizationService) Authorize(
+	ctx context.Context,
+	userId string,
+	req dto.AuthorizeRequest,
+) (dto.AuthorizeResponse, error) {
+	client, scopes, err := s.validateRequest(ctx, req)
+	if err != nil {
+		return s.errorResponse(client, req, err)
+	}
+
+	response := dto.AuthorizeResponse{
+		ClientName: client.Name,
+		Scopes:     scopes,
+	}
+
+	consent, err := s.consentRepository.FindByUserAndClient(ctx, s.db, userId, client.ID.String())
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.AuthorizeResponse{}, err
+	}
+	if err != nil || !coversScopes(consent.Scopes, scopes) {
+		response.ConsentRequired = true
+		return response, nil
+	}
+
+	response.RedirectTo, err = s.issueCode(ctx, s.db, client, userId, scopes, req)
+	if err != nil {
+		return dto.AuthorizeResponse{}, err
+	}
+
+	return response, nil
+}
+
+// Decide records the user's answer on the consent screen. Approving adds the
+// scopes to the stored consent and issues the code.
+func (s *authorizationService) Decide(
+	ctx context.Context,
+	userId string,
+	req dto.AuthorizeDecisionRequest,
+) (dto.AuthorizeResponse, error) {
+	client, scopes, err := s.validateRequest(ctx, req.AuthorizeRequest)
+	if err != nil {
+		return s.errorResponse(client, req.AuthorizeRequest, err)
+	}
+
+	response := dto.AuthorizeResponse{
+		ClientName: client.Name,
+		Scopes:     scopes,
+	}
+
+	if !req.Approve {
+		denied := dto.NewOAuthError(dto.OAUTH_ERROR_ACCESS_DENIED, "the user denied the request")
+		response.RedirectTo = errorRedirect(req.RedirectURI, req.State, denied)
+		return response, nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		granted := scopes
+		consent, err := s.consentRepository.FindByUserAndClient(ctx, tx, userId, client.ID.String())
+		if err == nil {
+			granted = uniqueScopes(append(consent.Scopes, scopes...))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := s.consentRepository.Save(ctx, tx, entities.OAuthConsent{
+			UserID:   uuid.MustParse(userId),
+			ClientID: client.ID,
+			Scopes:   granted,
+		}); err != nil {
+			return err
+		}
+
+		response.RedirectTo, err = s.issueCode(ctx, tx, client, userId, scopes, req.AuthorizeRequest)
+		return err
+	})
+	if err != nil {
+		return dto.AuthorizeResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (s *authorizationService) ListConsents(ctx context.Context, userId string) ([]dto.ConsentResponse, error) {
+	consents, err := s.consentRepository.FindByUserID(ctx, s.db, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.ConsentResponse, 0, len(consents))
+	for _, consent := range consents {
+		response = append(response, dto.ConsentResponse{
+			ClientID:   consent.Client.ClientID,
+			ClientName: consent.Client.Name,
+			Scopes:     consent.Scopes,
+			GrantedAt:  consent.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return response, nil
+}
+
+// RevokeConsent removes the user's consent for a client. Tokens already issued
+// stop passing introspection, and the next authorization asks again.
+func (s *authorizationService) RevokeConsent(ctx context.Context, userId string, clientId string) error {
+	client, err := s.clientRepository.FindByClientID(ctx, s.db, clientId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrConsentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.consentRepository.Delete(ctx, s.db, userId, client.ID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrConsentNotFound
+	}
+
+	return err
+}
+
+// validateRequest returns an ErrClientNotFound or ErrRedirectURIMismatch when
+// the client cannot be trusted with a redirect, and an OAuthError for the
+// errors that are reported back to the client
+func (s *authorizationService) validateRequest(
+	ctx context.Context,
+	req dto.AuthorizeRequest,
+) (entities.OAuthClient, []string, error) {
+	client, err := s.clientRepository.FindByClientID(ctx, s.db, req.ClientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.OAuthClient{}, nil, dto.ErrClientNotFound
+	}
+	if err != nil {
+		return entities.OAuthClient{}, nil, err
+	}
+
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return entities.OAuthClient{}, nil, dto.ErrRedirectURIMismatch
+	}
+
+	if req.ResponseType != "code" {
+		return client, nil, dto.NewOAuthError(dto.OAUTH_ERROR_UNSUPPORTED_RESPONSE_TYPE, "only the code response type is supported")
+	}
+
+	if !slices.Contains(client.GrantTypes, constants.ENUM_OAUTH_GRANT_AUTHORIZATION_CODE) {
+		return client, nil, dto.NewOAuthError(dto.OAUTH_ERROR_UNAUTHORIZED_CLIENT, "client may not use the authorization code grant")
+	}
+
+	scopes, ok := resolveScope(client.Scopes, req.Scope)
+	if !ok {
+		return client, nil, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_SCOPE, "scope not allowed for this client")
+	}
+
+	if req.CodeChallenge == "" && client.Public {
+		return client, nil, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_REQUEST, "public clients must use PKCE")
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != pkceMethodS256 {
+		return client, nil, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_REQUEST, "code_challenge_method must be S256")
+	}
+
+	return client, scopes, nil
+}
+
+// errorResponse sends OAuth errors back to the client's redirect uri. Errors
+// about the client or the redirect uri itself are returned as they are.
+func (s *authorizationService) errorResponse(
+	client entities.OAuthClient,
+	req dto.AuthorizeRequest,
+	err error,
+) (dto.AuthorizeResponse, error) {
+	var oauthErr *dto.OAuthError
+	if !errors.As(err, &oauthErr) {
+		return dto.AuthorizeResponse{}, err
+	}
+
+	return dto.AuthorizeResponse{
+		ClientName: client.Name,
+		RedirectTo: errorRedirect(req.RedirectURI, req.State, oauthErr),
+	}, nil
+}
+
+func (s *authorizationService) issueCode(
+	ctx context.Context,
+	tx *gorm.DB,
+	client entities.OAuthClient,
+	userId string,
+	scopes []string,
+	req dto.AuthorizeRequest,
+) (string, error) {
+	code, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.authorizationCodeRepository.Create(ctx, tx, entities.OAuthAuthorizationCode{
+		CodeHash:            helpers.HashToken(code),
+		ClientID:            client.ID,
+		UserID:              uuid.MustParse(userId),
+		RedirectURI:         req.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(authorizationCodeExpiry),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return redirectWithQuery(req.RedirectURI, url.Values{
+		"code":  {code},
+		"state": {req.State},
+	}), nil
+}
+
+func errorRedirect(redirectURI string, state string, err *dto.OAuthError) string {
+	return redirectWithQuery(redirectURI, url.Values{
+		"error":             {err.Code},
+		"error_description": {err.Description},
+		"state":             {state},
+	})
+}
+
+// redirectWithQuery adds the parameters to the redirect uri, keeping the query
+// it was registered with and leaving out empty values
+func redirectWithQuery(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			query.Set(key, values[0])
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// verifyCodeChallenge checks a PKCE verifier against the S256 challenge sent
+// with the authorization request
+func verifyCodeChallenge(challenge string, verifier string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"slices"
+
+	"blog/database/entities"
+	"blog/modules/oauth/dto"
+	"blog/modules/oauth/repository"
+	"blog/pkg/constants"
+	"blog/pkg/helpers"
+	"gorm.io/gorm"
+)
+
+type ClientService interface {
+	ListClients(ctx context.Context) ([]dto.ClientResponse, error)
+	CreateClient(ctx context.Context, req dto.ClientCreateRequest) (dto.ClientResponse, error)
+	DeleteClient(ctx context.Context, id string) error
+	AuthenticateClient(ctx context.Context, creds dto.ClientCredentials) (entities.OAuthClient, error)
+}
+
+type clientService struct {
+	clientRepository repository.ClientRepository
+	db               *gorm.DB
+}
+
+func NewClientService(clientRepo repository.ClientRepository, db *gorm.DB) ClientService {
+	return &clientService{
+		clientRepository: clientRepo,
+		db:               db,
+	}
+}
+
+func (s *clientService) ListClients(ctx context.Context) ([]dto.ClientResponse, error) {
+	clients, err := s.clientRepository.FindAll(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.ClientResponse, 0, len(clients))
+	for _, client := range clients {
+		response = append(response, toClientResponse(client))
+	}
+
+	return response, nil
+}
+
+// CreateClient registers a client. Confidential clients get a secret, which is
+// only returned here since just its hash is stored.
+func (s *clientService) CreateClient(ctx context.Context, req dto.ClientCreateRequest) (dto.ClientResponse, error) {
+	if slices.Contains(req.GrantTypes, constants.ENUM_OAUTH_GRANT_AUTHORIZATION_CODE) && len(req.RedirectURIs) == 0 {
+		return dto.ClientResponse{}, dto.ErrRedirectURIRequired
+	}
+	if req.Public && slices.Contains(req.GrantTypes, constants.ENUM_OAUTH_GRANT_CLIENT_CREDENTIALS) {
+		return dto.ClientResponse{}, dto.ErrPublicClientCredentials
+	}
+
+	clientId, err := helpers.GenerateRandomToken(16)
+	if err != nil {
+		return dto.ClientResponse{}, err
+	}
+
+	client := entities.OAuthClient{
+		ClientID:     clientId,
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       uniqueScopes(req.Scopes),
+		GrantTypes:   uniqueScopes(req.GrantTypes),
+		Public:       req.Public,
+	}
+	if client.RedirectURIs == nil {
+		client.RedirectURIs = []string{}
+	}
+
+	var secret string
+	if !req.Public {
+		secret, err = helpers.GenerateRandomToken(32)
+		if err != nil {
+			return dto.ClientResponse{}, err
+		}
+		client.SecretHash = helpers.HashToken(secret)
+	}
+
+	client, err = s.clientRepository.Create(ctx, s.db, client)
+	if err != nil {
+		return dto.ClientResponse{}, err
+	}
+
+	response := toClientResponse(client)
+	response.ClientSecret = secret
+	return response, nil
+}
+
+func (s *clientService) DeleteClient(ctx context.Context, id string) error {
+	err := s.clientRepository.Delete(ctx, s.db, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrClientNotFound
+	}
+
+	return err
+}
+
+// AuthenticateClient checks the credentials sent to the token endpoints.
+// Public clients only identify themselves, confidential clients must present
+// their secret.
+func (s *clientService) AuthenticateClient(ctx context.Context, creds dto.ClientCredentials) (entities.OAuthClient, error) {
+	if creds.ClientID == "" {
+		return entities.OAuthClient{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_CLIENT, "client authentication required")
+	}
+
+	client, err := s.clientRepository.FindByClientID(ctx, s.db, creds.ClientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entities.OAuthClient{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_CLIENT, "unknown client")
+	}
+	if err != nil {
+		return entities.OAuthClient{}, err
+	}
+
+	if client.Public {
+		if creds.ClientSecret != "" {
+			return entities.OAuthClient{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_CLIENT, "public clients have no secret")
+		}
+		return client, nil
+	}
+
+	hash := helpers.HashToken(creds.ClientSecret)
+	if creds.ClientSecret == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(client.SecretHash)) != 1 {
+		return entities.OAuthClient{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_CLIENT, "invalid client credentials")
+	}
+
+	return client, nil
+}
+
+func toClientResponse(client entities.OAuthClient) dto.ClientResponse {
+	return dto.ClientResponse{
+		ID:           client.ID.String(),
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		GrantTypes:   client.GrantTypes,
+		Public:       client.Public,
+	}
+}
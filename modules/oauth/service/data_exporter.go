@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"blog/modules/oauth/repository"
+	"blog/pkg/export"
+	"gorm.io/gorm"
+)
+
+// NewConsentExporter exports the applications a user granted access to
+func NewConsentExporter(consentRepo repository.ConsentRepository, db *gorm.DB) export.Exporter {
+	return export.NewExporter(
+		"oauth_consents",
+		func(ctx context.Context, userId string) (int64, error) {
+			return consentRepo.CountByUserID(ctx, db, userId)
+		},
+		func(ctx context.Context, userId string) ([]export.Record, error) {
+			consents, err := consentRepo.FindByUserID(ctx, db, userId)
+			if err != nil {
+				return nil, err
+			}
+			return export.ToRecords(consents)
+		},
+	)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"slices"
+	"strings"
+)
+
+// parseScope splits a space separated scope parameter, dropping duplicates
+func parseScope(scope string) []string {
+	return uniqueScopes(strings.Fields(scope))
+}
+
+func formatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+func uniqueScopes(scopes []string) []string {
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(unique, scope) {
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
+
+// coversScopes reports whether every requested scope is in granted
+func coversScopes(granted []string, requested []string) bool {
+	for _, scope := range requested {
+		if !slices.Contains(granted, scope) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveScope returns the requested scopes, or all the allowed ones when the
+// client did not ask for any. ok is false when a scope is not allowed.
+func resolveScope(allowed []string, scope string) ([]string, bool) {
+	requested := parseScope(scope)
+	if len(requested) == 0 {
+		return allowed, true
+	}
+
+	return requested, coversScopes(allowed, requested)
+}
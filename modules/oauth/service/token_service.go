@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"blog/database/entities"
+	authService "blog/modules/auth/service"
+	"blog/modules/oauth/dto"
+	"blog/modules/oauth/repository"
+	"blog/pkg/constants"
+	"blog/pkg/helpers"
+	"gorm.io/gorm"
+)
+
+const tokenTypeBearer = "Bearer"
+
+// TokenService implements the endpoints clients call directly: the token
+// endpoint (RFC 6749), introspection (RFC 7662) and revocation (RFC 7009)
+type TokenService interface {
+	Token(ctx context.Context, creds dto.ClientCredentials, req dto.TokenRequest) (dto.TokenResponse, error)
+	Introspect(ctx context.Context, creds dto.ClientCredentials, req dto.IntrospectRequest) (dto.IntrospectionResponse, error)
+	Revoke(ctx context.Context, creds dto.ClientCredentials, req dto.RevokeRequest) error
+}
+
+type tokenService struct {
+	clientService               ClientService
+	clientRepository            repository.ClientRepository
+	authorizationCodeRepository repository.AuthorizationCodeRepository
+	consentRepository           repository.ConsentRepository
+	jwtService                  authService.JWTService
+	revocationService           authService.TokenRevocationService
+	db                          *gorm.DB
+}
+
+func NewTokenService(
+	clientService ClientService,
+	clientRepo repository.ClientRepository,
+	authorizationCodeRepo repository.AuthorizationCodeRepository,
+	consentRepo repository.ConsentRepository,
+	jwtService authService.JWTService,
+	revocationService authService.TokenRevocationService,
+	db *gorm.DB,
+) TokenService {
+	return &tokenService{
+		clientService:               clientService,
+		clientRepository:            clientRepo,
+		authorizationCodeRepository: authorizationCodeRepo,
+		consentRepository:           consentRepo,
+		jwtService:                  jwtService,
+		revocationService:           revocationService,
+		db:                          db,
+	}
+}
+
+func (s *tokenService) Token(ctx context.Context, creds dto.ClientCredentials, req dto.TokenRequest) (dto.TokenResponse, error) {
+	client, err := s.clientService.AuthenticateClient(ctx, creds)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	switch req.GrantType {
+	case constants.ENUM_OAUTH_GRANT_AUTHORIZATION_CODE, constants.ENUM_OAUTH_GRANT_CLIENT_CREDENTIALS:
+	default:
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_UNSUPPORTED_GRANT_TYPE, "")
+	}
+
+	if !slices.Contains(client.GrantTypes, req.GrantType) {
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_UNAUTHORIZED_CLIENT, "client may not use this grant type")
+	}
+
+	if req.GrantType == constants.ENUM_OAUTH_GRANT_CLIENT_CREDENTIALS {
+		return s.clientCredentials(client, req)
+	}
+
+	return s.authorizationCode(ctx, client, req)
+}
+
+// authorizationCode exchanges a code issued by the authorize endpoint. The code
+// is consumed before the token is signed, so it can never be exchanged twice.
+func (s *tokenService) authorizationCode(
+	ctx context.Context,
+	client entities.OAuthClient,
+	req dto.TokenRequest,
+) (dto.TokenResponse, error) {
+	invalidGrant := dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_GRANT, "authorization code invalid or expired")
+
+	if req.Code == "" {
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_REQUEST, "code is required")
+	}
+
+	code, err := s.authorizationCodeRepository.FindByHash(ctx, s.db, helpers.HashToken(req.Code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.TokenResponse{}, invalidGrant
+	}
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	if code.ClientID != client.ID || code.ConsumedAt != nil || time.Now().After(code.ExpiresAt) {
+		return dto.TokenResponse{}, invalidGrant
+	}
+
+	if req.RedirectURI != code.RedirectURI {
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_GRANT, "redirect_uri does not match the authorization request")
+	}
+
+	if code.CodeChallenge != "" && !verifyCodeChallenge(code.CodeChallenge, req.CodeVerifier) {
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_GRANT, "code_verifier does not match the code_challenge")
+	}
+
+	err = s.authorizationCodeRepository.Consume(ctx, s.db, code.ID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.TokenResponse{}, invalidGrant
+	}
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	return s.issueToken(code.UserID.String(), client, code.Scopes)
+}
+
+// clientCredentials issues a token to the client acting on its own behalf
+func (s *tokenService) clientCredentials(client entities.OAuthClient, req dto.TokenRequest) (dto.TokenResponse, error) {
+	if client.Public {
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_UNAUTHORIZED_CLIENT, dto.ErrPublicClientCredentials.Error())
+	}
+
+	scopes, ok := resolveScope(client.Scopes, req.Scope)
+	if !ok {
+		return dto.TokenResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_INVALID_SCOPE, "scope not allowed for this client")
+	}
+
+	return s.issueToken("", client, scopes)
+}
+
+func (s *tokenService) issueToken(userId string, client entities.OAuthClient, scopes []string) (dto.TokenResponse, error) {
+	scope := formatScope(scopes)
+	accessToken, err := s.jwtService.GenerateClientAccessToken(userId, client.ClientID, scope)
+	if err != nil {
+		return dto.TokenResponse{}, err
+	}
+
+	return dto.TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int64(s.jwtService.AccessTokenExpiry().Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// Introspect tells a resource server whether a token is still good. Only
+// confidential clients may ask. Besides the signature and revocation, the
+// client must still exist and, for user tokens, the consent must not have
+// been withdrawn.
+func (s *tokenService) Introspect(
+	ctx context.Context,
+	creds dto.ClientCredentials,
+	req dto.IntrospectRequest,
+) (dto.IntrospectionResponse, error) {
+	caller, err := s.clientService.AuthenticateClient(ctx, creds)
+	if err != nil {
+		return dto.IntrospectionResponse{}, err
+	}
+	if caller.Public {
+		return dto.IntrospectionResponse{}, dto.NewOAuthError(dto.OAUTH_ERROR_UNAUTHORIZED_CLIENT, "public clients may not introspect tokens")
+	}
+
+	inactive := dto.IntrospectionResponse{Active: false}
+
+	claims, err := s.jwtService.ValidateToken(req.Token)
+	if err != nil || claims.Purpose != constants.ENUM_TOKEN_PURPOSE_OAUTH_ACCESS {
+		return inactive, nil
+	}
+
+	revoked, err := s.revocationService.IsRevoked(ctx, claims)
+	if err != nil {
+		return dto.IntrospectionResponse{}, err
+	}
+	if revoked {
+		return inactive, nil
+	}
+
+	client, err := s.clientRepository.FindByClientID(ctx, s.db, claims.ClientID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return inactive, nil
+	}
+	if err != nil {
+		return dto.IntrospectionResponse{}, err
+	}
+
+	if claims.UserID != "" {
+		consent, err := s.consentRepository.FindByUserAndClient(ctx, s.db, claims.UserID, client.ID.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return inactive, nil
+		}
+		if err != nil {
+			return dto.IntrospectionResponse{}, err
+		}
+		if !coversScopes(consent.Scopes, parseScope(claims.Scope)) {
+			return inactive, nil
+		}
+	}
+
+	response := dto.IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Subject:   claims.Subject,
+		TokenType: tokenTypeBearer,
+		Issuer:    claims.Issuer,
+		JTI:       claims.ID,
+	}
+	if claims.ExpiresAt != nil {
+		response.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		response.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		response.NotBefore = claims.NotBefore.Unix()
+	}
+
+	return response, nil
+}
+
+// Revoke invalidates an access token issued to the calling client. As RFC
+// 7009 asks, unknown tokens and tokens of other clients are silently ignored.
+func (s *tokenService) Revoke(ctx context.Context, creds dto.ClientCredentials, req dto.RevokeRequest) error {
+	client, err := s.clientService.AuthenticateClient(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	claims, err := s.jwtService.ValidateToken(req.Token)
+	if err != nil || claims.Purpose != constants.ENUM_TOKEN_PURPOSE_OAUTH_ACCESS || claims.ClientID != client.ClientID {
+		return nil
+	}
+
+	return s.revocationService.RevokeToken(ctx, req.Token)
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/rbac/dto"
+	"blog/modules/rbac/service"
+	userDto "blog/modules/user/dto"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	RBACController interface {
+		ListRoles(ctx *gin.Context)
+		CreateRole(ctx *gin.Context)
+		UpdateRolePermissions(ctx *gin.Context)
+		ListPermissions(ctx *gin.Context)
+		AssignRole(ctx *gin.Context)
+	}
+
+	rbacController struct {
+		rbacService service.RBACService
+	}
+)
+
+func NewRBACController(rs service.RBACService) RBACController {
+	return &rbacController{
+		rbacService: rs,
+	}
+}
+
+func (c *rbacController) ListRoles(ctx *gin.Context) {
+	result, err := c.rbacService.ListRoles(ctx.Request.Context())
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ROLES, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ROLES, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *rbacController) CreateRole(ctx *gin.Context) {
+	var req dto.RoleCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.rbacService.CreateRole(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ROLE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *rbacController) UpdateRolePermissions(ctx *gin.Context) {
+	var req dto.RolePermissionsRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.rbacService.UpdateRolePermissions(ctx.Request.Context(), ctx.Param("name"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_ROLE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *rbacController) ListPermissions(ctx *gin.Context) {
+	result, err := c.rbacService.ListPermissions(ctx.Request.Context())
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PERMISSIONS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_PERMISSIONS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *rbacController) AssignRole(ctx *gin.Context) {
+	var req dto.AssignRoleRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(userDto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.rbacService.AssignRole(ctx.Request.Context(), ctx.Param("id"), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ASSIGN_ROLE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ASSIGN_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_GET_ROLES       = "failed get roles"
+	MESSAGE_FAILED_CREATE_ROLE     = "failed create role"
+	MESSAGE_FAILED_UPDATE_ROLE     = "failed update role"
+	MESSAGE_FAILED_GET_PERMISSIONS = "failed get permissions"
+	MESSAGE_FAILED_ASSIGN_ROLE     = "failed assign role"
+
+	// Success
+	MESSAGE_SUCCESS_GET_ROLES       = "success get roles"
+	MESSAGE_SUCCESS_CREATE_ROLE     = "success create role"
+	MESSAGE_SUCCESS_UPDATE_ROLE     = "success update role"
+	MESSAGE_SUCCESS_GET_PERMISSIONS = "success get permissions"
+	MESSAGE_SUCCESS_ASSIGN_ROLE     = "success assign role"
+)
+
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleAlreadyExists  = errors.New("role already exist")
+	ErrPermissionNotFound = errors.New("permission not found")
+)
+
+type (
+	RoleResponse struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	PermissionResponse struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	RoleCreateRequest struct {
+		Name        string   `json:"name" binding:"required,min=2,max=50"`
+		Description string   `json:"description" binding:"omitempty,max=255"`
+		Permissions []string `json:"permissions" binding:"omitempty,dive,required"`
+	}
+
+	RolePermissionsRequest struct {
+		Permissions []string `json:"permissions" binding:"omitempty,dive,required"`
+	}
+
+	AssignRoleRequest struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	AssignRoleResponse struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	}
+)
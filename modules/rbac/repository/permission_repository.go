@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type PermissionRepository interface {
+	FindAll(ctx context.Context, tx *gorm.DB) ([]entities.Permission, error)
+	FindByNames(ctx context.Context, tx *gorm.DB, names []string) ([]entities.Permission, error)
+}
+
+type permissionRepository struct {
+	db *gorm.DB
+}
+
+func NewPermissionRepository(db *gorm.DB) PermissionRepository {
+	return &permissionRepository{
+		db: db,
+	}
+}
+
+func (r *permissionRepository) FindAll(ctx context.Context, tx *gorm.DB) ([]entities.Permission, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var permissions []entities.Permission
+	if err := tx.WithContext(ctx).Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *permissionRepository) FindByNames(ctx context.Context, tx *gorm.DB, names []string) ([]entities.Permission, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var permissions []entities.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	if err := tx.WithContext(ctx).Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, role entities.Role) (entities.Role, error)
+	FindAll(ctx context.Context, tx *gorm.DB) ([]entities.Role, error)
+	FindByName(ctx context.Context, tx *gorm.DB, name string) (entities.Role, error)
+	ReplacePermissions(ctx context.Context, tx *gorm.DB, role *entities.Role, permissions []entities.Permission) error
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{
+		db: db,
+	}
+}
+
+func (r *roleRepository) Create(ctx context.Context, tx *gorm.DB, role entities.Role) (entities.Role, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&role).Error; err != nil {
+		return entities.Role{}, err
+	}
+
+	return role, nil
+}
+
+func (r *roleRepository) FindAll(ctx context.Context, tx *gorm.DB) ([]entities.Role, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var roles []entities.Role
+	if err := tx.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, tx *gorm.DB, name string) (entities.Role, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var role entities.Role
+	if err := tx.WithContext(ctx).Preload("Permissions").Where("name = ?", name).Take(&role).Error; err != nil {
+		return entities.Role{}, err
+	}
+
+	return role, nil
+}
+
+func (r *roleRepository) ReplacePermissions(
+	ctx context.Context,
+	tx *gorm.DB,
+	role *entities.Role,
+	permissions []entities.Permission,
+) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).Model(role).Association("Permissions").Replace(permissions)
+}
@@ -0,0 +1,29 @@
+package rbac
+
+import (
+	"blog/middlewares"
+	apiKeyService "blog/modules/apikey/service"
+	authService "blog/modules/auth/service"
+	"blog/modules/rbac/controller"
+	"blog/modules/rbac/service"
+	"blog/pkg/constants"
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func RegisterRoutes(router *gin.Engine, injector *do.Injector) {
+	rbacController := do.MustInvoke[controller.RBACController](injector)
+	rbacService := do.MustInvokeNamed[service.RBACService](injector, constants.RBACService)
+	jwtService := do.MustInvokeNamed[authService.JWTService](injector, constants.JWTService)
+	revocationService := do.MustInvokeNamed[authService.TokenRevocationService](injector, constants.TokenRevocationService)
+	apiKeyService := do.MustInvokeNamed[apiKeyService.APIKeyService](injector, constants.APIKeyService)
+
+	adminRoutes := router.Group("/api/v1/admin", middlewares.AuthenticateWithAPIKey(jwtService, revocationService, apiKeyService))
+	{
+		adminRoutes.GET("/roles", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_ROLE_READ), rbacController.ListRoles)
+		adminRoutes.POST("/roles", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_ROLE_MANAGE), rbacController.CreateRole)
+		adminRoutes.PUT("/roles/:name/permissions", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_ROLE_MANAGE), rbacController.UpdateRolePermissions)
+		adminRoutes.GET("/permissions", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_ROLE_READ), rbacController.ListPermissions)
+		adminRoutes.PUT("/users/:id/role", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_ROLE_MANAGE), rbacController.AssignRole)
+	}
+}
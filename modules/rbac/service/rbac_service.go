@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"blog/database/entities"
+	authService "blog/modules/auth/service"
+	"blog/modules/rbac/dto"
+	"blog/modules/rbac/repository"
+	userDto "blog/modules/user/dto"
+	userRepo "blog/modules/user/repository"
+	"blog/pkg/cache"
+	"gorm.io/gorm"
+)
+
+const (
+	rolePermissionsKeyPrefix = "rbac:role:"
+	rolePermissionsTTL       = time.Minute * 5
+)
+
+type RBACService interface {
+	ListRoles(ctx context.Context) ([]dto.RoleResponse, error)
+	CreateRole(ctx context.Context, req dto.RoleCreateRequest) (dto.RoleResponse, error)
+	UpdateRolePermissions(ctx context.Context, name string, req dto.RolePermissionsRequest) (dto.RoleResponse, error)
+	ListPermissions(ctx context.Context) ([]dto.PermissionResponse, error)
+	AssignRole(ctx context.Context, userId string, req dto.AssignRoleRequest) (dto.AssignRoleResponse, error)
+	// CheckRole returns ErrRoleNotFound unless the role exists, for callers
+	// writing the role of a user in their own transaction
+	CheckRole(ctx context.Context, role string) error
+	HasPermissions(ctx context.Context, role string, permissions ...string) (bool, error)
+}
+
+type rbacService struct {
+	roleRepository       repository.RoleRepository
+	permissionRepository repository.PermissionRepository
+	userRepository       userRepo.UserRepository
+	revocationService    authService.TokenRevocationService
+	store                cache.Store
+	db                   *gorm.DB
+}
+
+func NewRBACService(
+	roleRepo repository.RoleRepository,
+	permissionRepo repository.PermissionRepository,
+	userRepo userRepo.UserRepository,
+	revocationService authService.TokenRevocationService,
+	store cache.Store,
+	db *gorm.DB,
+) RBACService {
+	return &rbacService{
+		roleRepository:       roleRepo,
+		permissionRepository: permissionRepo,
+		userRepository:       userRepo,
+		revocationService:    revocationService,
+		store:                store,
+		db:                   db,
+	}
+}
+
+func (s *rbacService) ListRoles(ctx context.Context) ([]dto.RoleResponse, error) {
+	roles, err := s.roleRepository.FindAll(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, toRoleResponse(role))
+	}
+
+	return response, nil
+}
+
+func (s *rbacService) CreateRole(ctx context.Context, req dto.RoleCreateRequest) (dto.RoleResponse, error) {
+	var role entities.Role
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := s.roleRepository.FindByName(ctx, tx, req.Name)
+		if err == nil {
+			return dto.ErrRoleAlreadyExists
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		permissions, err := s.findPermissions(ctx, tx, req.Permissions)
+		if err != nil {
+			return err
+		}
+
+		role, err = s.roleRepository.Create(ctx, tx, entities.Role{
+			Name:        req.Name,
+			Description: req.Description,
+			Permissions: permissions,
+		})
+		return err
+	})
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	return toRoleResponse(role), nil
+}
+
+func (s *rbacService) UpdateRolePermissions(
+	ctx context.Context,
+	name string,
+	req dto.RolePermissionsRequest,
+) (dto.RoleResponse, error) {
+	var role entities.Role
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		role, err = s.roleRepository.FindByName(ctx, tx, name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrRoleNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		permissions, err := s.findPermissions(ctx, tx, req.Permissions)
+		if err != nil {
+			return err
+		}
+
+		return s.roleRepository.ReplacePermissions(ctx, tx, &role, permissions)
+	})
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	if err := s.store.Delete(ctx, rolePermissionsKeyPrefix+role.Name); err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	return toRoleResponse(role), nil
+}
+
+func (s *rbacService) ListPermissions(ctx context.Context) ([]dto.PermissionResponse, error) {
+	permissions, err := s.permissionRepository.FindAll(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.PermissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		response = append(response, dto.PermissionResponse{
+			ID:          permission.ID.String(),
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+
+	return response, nil
+}
+
+// AssignRole changes the role of a user. Access tokens carry the role, so the
+// ones already issued are revoked and the next refresh picks up the new role.
+func (s *rbacService) AssignRole(
+	ctx context.Context,
+	userId string,
+	req dto.AssignRoleRequest,
+) (dto.AssignRoleResponse, error) {
+	if _, err := s.roleRepository.FindByName(ctx, s.db, req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.AssignRoleResponse{}, dto.ErrRoleNotFound
+		}
+		return dto.AssignRoleResponse{}, err
+	}
+
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.AssignRoleResponse{}, userDto.ErrUserNotFound
+	}
+
+	if user.Role != req.Role {
+		if _, err := s.userRepository.Update(ctx, s.db, entities.User{ID: user.ID, Role: req.Role}); err != nil {
+			return dto.AssignRoleResponse{}, userDto.ErrUpdateUser
+		}
+
+		if err := s.revocationService.RevokeUserTokens(ctx, userId); err != nil {
+			return dto.AssignRoleResponse{}, err
+		}
+	}
+
+	return dto.AssignRoleResponse{
+		UserID: user.ID.String(),
+		Role:   req.Role,
+	}, nil
+}
+
+func (s *rbacService) CheckRole(ctx context.Context, role string) error {
+	if _, err := s.roleRepository.FindByName(ctx, s.db, role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrRoleNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// HasPermissions reports whether the role grants every given permission. The
+// permissions of a role are cached briefly, since this runs on every request
+// to a protected route.
+func (s *rbacService) HasPermissions(ctx context.Context, role string, permissions ...string) (bool, error) {
+	granted, err := s.rolePermissions(ctx, role)
+	if err != nil {
+		return false, err
+	}
+
+	for _, permission := range permissions {
+		if _, ok := granted[permission]; !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (s *rbacService) rolePermissions(ctx context.Context, name string) (map[string]struct{}, error) {
+	key := rolePermissionsKeyPrefix + name
+
+	value, found, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		role, err := s.roleRepository.FindByName(ctx, s.db, name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			names = append(names, permission.Name)
+		}
+
+		value = strings.Join(names, ",")
+		if err := s.store.Set(ctx, key, value, rolePermissionsTTL); err != nil {
+			return nil, err
+		}
+	}
+
+	granted := make(map[string]struct{})
+	for _, permission := range strings.Split(value, ",") {
+		if permission != "" {
+			granted[permission] = struct{}{}
+		}
+	}
+
+	return granted, nil
+}
+
+// findPermissions resolves permission names, failing if any of them is unknown
+func (s *rbacService) findPermissions(ctx context.Context, tx *gorm.DB, names []string) ([]entities.Permission, error) {
+	permissions, err := s.permissionRepository.FindByNames(ctx, tx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]struct{}, len(permissions))
+	for _, permission := range permissions {
+		found[permission.Name] = struct{}{}
+	}
+
+	for _, name := range names {
+		if _, ok := found[name]; !ok {
+			return nil, dto.ErrPermissionNotFound
+		}
+	}
+
+	return permissions, nil
+}
+
+func toRoleResponse(role entities.Role) dto.RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+
+	return dto.RoleResponse{
+		ID:          role.ID.String(),
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http"
+
+	"blog/modules/user/dto"
+	"blog/modules/user/service"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	AvatarController interface {
+		Upload(ctx *gin.Context)
+	}
+
+	avatarController struct {
+		avatarService service.AvatarService
+	}
+)
+
+func NewAvatarController(as service.AvatarService) AvatarController {
+	return &avatarController{
+		avatarService: as,
+	}
+}
+
+func (c *avatarController) Upload(ctx *gin.Context) {
+	var req dto.AvatarUploadRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.avatarService.Upload(ctx.Request.Context(), userId, req.Avatar)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPLOAD_AVATAR, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPLOAD_AVATAR, result)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	authDto "blog/modules/auth/dto"
+	"blog/modules/user/dto"
+	"blog/modules/user/service"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	DataExportController interface {
+		Export(ctx *gin.Context)
+	}
+
+	dataExportController struct {
+		dataExportService service.DataExportService
+	}
+)
+
+func NewDataExportController(des service.DataExportService) DataExportController {
+	return &dataExportController{
+		dataExportService: des,
+	}
+}
+
+func (c *dataExportController) Export(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	result, err := c.dataExportService.Export(ctx.Request.Context(), userId)
+	if err != nil {
+		status := http.StatusBadRequest
+		var retryErr *authDto.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(retryErr.Seconds()))
+			status = http.StatusTooManyRequests
+		}
+
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_EXPORT_DATA, err.Error(), nil)
+		ctx.JSON(status, res)
+		return
+	}
+
+	if result.Queued != nil {
+		res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_EXPORT_QUEUED, result.Queued)
+		ctx.JSON(http.StatusAccepted, res)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", result.Filename))
+	ctx.Data(http.StatusOK, "application/zip", result.Archive)
+}
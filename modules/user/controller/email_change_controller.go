@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	authDto "blog/modules/auth/dto"
+	"blog/modules/user/dto"
+	"blog/modules/user/service"
+	"blog/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	EmailChangeController interface {
+		RequestChange(ctx *gin.Context)
+		ConfirmChange(ctx *gin.Context)
+	}
+
+	emailChangeController struct {
+		emailChangeService service.EmailChangeService
+	}
+)
+
+func NewEmailChangeController(ecs service.EmailChangeService) EmailChangeController {
+	return &emailChangeController{
+		emailChangeService: ecs,
+	}
+}
+
+func (c *emailChangeController) RequestChange(ctx *gin.Context) {
+	var req dto.EmailChangeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	userId := ctx.MustGet("user_id").(string)
+	if err := c.emailChangeService.RequestChange(ctx.Request.Context(), userId, ctx.ClientIP(), req); err != nil {
+		status := http.StatusBadRequest
+		var retryErr *authDto.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(retryErr.Seconds()))
+			status = http.StatusTooManyRequests
+		}
+
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REQUEST_EMAIL_CHANGE, err.Error(), nil)
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_REQUEST_EMAIL_CHANGE, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (c *emailChangeController) ConfirmChange(ctx *gin.Context) {
+	var req dto.ConfirmEmailChangeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.emailChangeService.ConfirmChange(ctx.Request.Context(), req)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CONFIRM_EMAIL_CHANGE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CONFIRM_EMAIL_CHANGE, result)
+	ctx.JSON(http.StatusOK, res)
+}
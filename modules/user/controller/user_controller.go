@@ -1,16 +1,21 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
+	"blog/middlewares"
 	authDto "blog/modules/auth/dto"
+	authService "blog/modules/auth/service"
 	"blog/modules/user/dto"
+	"blog/modules/user/policy"
 	"blog/modules/user/query"
 	"blog/modules/user/service"
 	"blog/pkg/constants"
+	pagination "blog/pkg/helpers/pagination"
 	"blog/pkg/utils"
 	"github.com/gin-gonic/gin"
-	pagination "blog/pkg/helpers/pagination"
 	"github.com/samber/do"
 	"gorm.io/gorm"
 )
@@ -25,23 +30,39 @@ type (
 		SendVerificationEmail(ctx *gin.Context)
 		VerifyEmail(ctx *gin.Context)
 		Update(ctx *gin.Context)
+		AdminUpdate(ctx *gin.Context)
 		Delete(ctx *gin.Context)
+		Restore(ctx *gin.Context)
+		Erase(ctx *gin.Context)
 	}
 
 	userController struct {
-		userService service.UserService
-		db          *gorm.DB
+		userService   service.UserService
+		authService   authService.AuthService
+		userPolicy    policy.UserPolicy
+		avatarService service.AvatarService
+		db            *gorm.DB
 	}
 )
 
-func NewUserController(injector *do.Injector, us service.UserService) UserController {
+func NewUserController(
+	injector *do.Injector,
+	us service.UserService,
+	as authService.AuthService,
+	up policy.UserPolicy,
+	avs service.AvatarService,
+) UserController {
 	db := do.MustInvokeNamed[*gorm.DB](injector, constants.DB)
 	return &userController{
-		userService: us,
-		db:          db,
+		userService:   us,
+		authService:   as,
+		userPolicy:    up,
+		avatarService: avs,
+		db:            db,
 	}
 }
 
+// Deprecated: use /api/v1/auth/register
 func (c *userController) Register(ctx *gin.Context) {
 	var user dto.UserCreateRequest
 	if err := ctx.ShouldBind(&user); err != nil {
@@ -50,7 +71,7 @@ func (c *userController) Register(ctx *gin.Context) {
 		return
 	}
 
-	result, err := c.userService.Register(ctx.Request.Context(), user)
+	result, err := c.authService.Register(ctx.Request.Context(), user)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_REGISTER_USER, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
@@ -74,6 +95,10 @@ func (c *userController) GetAllUser(ctx *gin.Context) {
 		return
 	}
 
+	for i := range users {
+		users[i].ImageUrl = c.avatarService.URL(ctx.Request.Context(), users[i].ImageUrl, users[i].AvatarKey)
+	}
+
 	paginationResponse := pagination.CalculatePagination(filter.Pagination, total)
 	response := pagination.NewPaginatedResponse(http.StatusOK, dto.MESSAGE_SUCCESS_GET_LIST_USER, users, paginationResponse)
 	ctx.JSON(http.StatusOK, response)
@@ -93,6 +118,7 @@ func (c *userController) Me(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// Deprecated: use /api/v1/auth/login
 func (c *userController) Login(ctx *gin.Context) {
 	var req dto.UserLoginRequest
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -101,17 +127,31 @@ func (c *userController) Login(ctx *gin.Context) {
 		return
 	}
 
-	result, err := c.userService.Verify(ctx.Request.Context(), req)
+	session := authDto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), req.DeviceLabel)
+	result, err := c.authService.Login(ctx.Request.Context(), req, session)
 	if err != nil {
+		status := http.StatusBadRequest
+		var retryErr *authDto.RetryAfterError
+		if errors.As(err, &retryErr) {
+			ctx.Header("Retry-After", strconv.Itoa(retryErr.Seconds()))
+			status = http.StatusTooManyRequests
+		}
+
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_LOGIN, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(status, res)
 		return
 	}
 
-	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_LOGIN, result)
+	message := dto.MESSAGE_SUCCESS_LOGIN
+	if result.TwoFactorRequired {
+		message = authDto.MESSAGE_SUCCESS_LOGIN_TWO_FACTOR_REQUIRED
+	}
+
+	res := utils.BuildResponseSuccess(message, result)
 	ctx.JSON(http.StatusOK, res)
 }
 
+// Deprecated: use /api/v1/auth/send-verification-email
 func (c *userController) SendVerificationEmail(ctx *gin.Context) {
 	var req dto.SendVerificationEmailRequest
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -120,9 +160,9 @@ func (c *userController) SendVerificationEmail(ctx *gin.Context) {
 		return
 	}
 
-	err := c.userService.SendVerificationEmail(ctx.Request.Context(), req)
+	err := c.authService.SendVerificationEmail(ctx.Request.Context(), req)
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROCESS_REQUEST, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
@@ -131,6 +171,7 @@ func (c *userController) SendVerificationEmail(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// Deprecated: use /api/v1/auth/verify-email
 func (c *userController) VerifyEmail(ctx *gin.Context) {
 	var req dto.VerifyEmailRequest
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -139,7 +180,7 @@ func (c *userController) VerifyEmail(ctx *gin.Context) {
 		return
 	}
 
-	result, err := c.userService.VerifyEmail(ctx.Request.Context(), req)
+	result, err := c.authService.VerifyEmail(ctx.Request.Context(), req)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_VERIFY_EMAIL, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
@@ -158,7 +199,21 @@ func (c *userController) Update(ctx *gin.Context) {
 		return
 	}
 
-	userId := ctx.MustGet("user_id").(string)
+	userId := ctx.Param("id")
+	claims, _ := middlewares.GetClaims(ctx)
+	allowed, err := c.userPolicy.CanUpdate(ctx.Request.Context(), claims, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_USER, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	if !allowed {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_USER, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, res)
+		return
+	}
+
 	result, err := c.userService.Update(ctx.Request.Context(), req, userId)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_USER, err.Error(), nil)
@@ -170,8 +225,56 @@ func (c *userController) Update(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+func (c *userController) AdminUpdate(ctx *gin.Context) {
+	var req dto.UserAdminUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if req.Role != "" {
+		claims, _ := middlewares.GetClaims(ctx)
+		allowed, err := c.userPolicy.CanAssignRole(ctx.Request.Context(), claims)
+		if err != nil {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_USER, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, res)
+			return
+		}
+
+		if !allowed {
+			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_USER, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+	}
+
+	result, err := c.userService.AdminUpdate(ctx.Request.Context(), req, ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_USER, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_USER, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
 func (c *userController) Delete(ctx *gin.Context) {
-	userId := ctx.MustGet("user_id").(string)
+	userId := ctx.Param("id")
+	claims, _ := middlewares.GetClaims(ctx)
+	allowed, err := c.userPolicy.CanDelete(ctx.Request.Context(), claims, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_USER, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	if !allowed {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_USER, dto.MESSAGE_FAILED_DENIED_ACCESS, nil)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, res)
+		return
+	}
 
 	if err := c.userService.Delete(ctx.Request.Context(), userId); err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_USER, err.Error(), nil)
@@ -183,6 +286,34 @@ func (c *userController) Delete(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, res)
 }
 
+// Restore is reached through the admin routes only, RequirePermission has
+// already checked the caller
+func (c *userController) Restore(ctx *gin.Context) {
+	result, err := c.userService.Restore(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_RESTORE_USER, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_RESTORE_USER, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Erase is reached through the admin routes only, RequirePermission has
+// already checked the caller
+func (c *userController) Erase(ctx *gin.Context) {
+	if err := c.userService.Erase(ctx.Request.Context(), ctx.Param("id")); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ERASE_USER, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ERASE_USER, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Deprecated: use /api/v1/auth/refresh
 func (c *userController) Refresh(ctx *gin.Context) {
 	var req authDto.RefreshTokenRequest
 	if err := ctx.ShouldBind(&req); err != nil {
@@ -191,7 +322,8 @@ func (c *userController) Refresh(ctx *gin.Context) {
 		return
 	}
 
-	result, err := c.userService.RefreshToken(ctx.Request.Context(), req)
+	session := authDto.NewSessionInfo(ctx.Request.UserAgent(), ctx.ClientIP(), "")
+	result, err := c.authService.RefreshToken(ctx.Request.Context(), req, session)
 	if err != nil {
 		res := utils.BuildResponseFailed(authDto.MESSAGE_FAILED_REFRESH_TOKEN, err.Error(), nil)
 		ctx.JSON(http.StatusUnauthorized, res)
@@ -200,4 +332,4 @@ func (c *userController) Refresh(ctx *gin.Context) {
 
 	res := utils.BuildResponseSuccess(authDto.MESSAGE_SUCCESS_REFRESH_TOKEN, result)
 	ctx.JSON(http.StatusOK, res)
-}
\ No newline at end of file
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"errors"
+	"mime/multipart"
+)
+
+const (
+	MESSAGE_FAILED_UPLOAD_AVATAR  = "failed upload avatar"
+	MESSAGE_SUCCESS_UPLOAD_AVATAR = "success upload avatar"
+)
+
+var (
+	ErrAvatarTooLarge        = errors.New("avatar file too large")
+	ErrAvatarUnsupportedType = errors.New("avatar must be a jpeg, png, gif or webp image")
+	ErrAvatarDimensions      = errors.New("avatar width or height out of bounds")
+	ErrAvatarChanged         = errors.New("avatar was changed by another upload, try again")
+)
+
+type (
+	AvatarUploadRequest struct {
+		Avatar *multipart.FileHeader `form:"avatar" binding:"required"`
+	}
+
+	AvatarResponse struct {
+		ImageUrl string `json:"image_url"`
+		// Thumbnails maps the side length in pixels to the URL of that size
+		Thumbnails map[string]string `json:"thumbnails"`
+	}
+)
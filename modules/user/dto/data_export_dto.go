@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	MESSAGE_FAILED_EXPORT_DATA    = "failed export data"
+	MESSAGE_SUCCESS_EXPORT_QUEUED = "success export queued, a download link will be emailed"
+)
+
+var (
+	ErrTooManyDataExports = errors.New("too many data exports, try again later")
+)
+
+type (
+	DataExportQueuedResponse struct {
+		ID string `json:"id"`
+	}
+
+	// DataExportResult carries the archive itself, or Queued when it was too
+	// large to return and is emailed as a link instead
+	DataExportResult struct {
+		Archive  []byte
+		Filename string
+		Queued   *DataExportQueuedResponse
+	}
+)
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"errors"
+)
+
+const (
+	MESSAGE_FAILED_REQUEST_EMAIL_CHANGE  = "failed request email change"
+	MESSAGE_SUCCESS_REQUEST_EMAIL_CHANGE = "success request email change, confirm it from the new address"
+	MESSAGE_FAILED_CONFIRM_EMAIL_CHANGE  = "failed confirm email change"
+	MESSAGE_SUCCESS_CONFIRM_EMAIL_CHANGE = "success confirm email change"
+)
+
+var (
+	ErrEmailUnchanged          = errors.New("new email is the current email")
+	ErrEmailChangeToken        = errors.New("email change link invalid or expired")
+	ErrEmailChangeNeedsConfirm = errors.New("email can only be changed by confirming the new address")
+)
+
+type (
+	EmailChangeRequest struct {
+		NewEmail        string `json:"new_email" form:"new_email" binding:"required,email,max=255"`
+		CurrentPassword string `json:"current_password" form:"current_password" binding:"required"`
+	}
+
+	ConfirmEmailChangeRequest struct {
+		Token string `json:"token" form:"token" binding:"required"`
+	}
+
+	EmailChangeResponse struct {
+		Email      string `json:"email"`
+		IsVerified bool   `json:"is_verified"`
+	}
+)
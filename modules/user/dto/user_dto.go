@@ -15,13 +15,16 @@ const (
 	MESSAGE_FAILED_GET_LIST_USER      = "failed get list user"
 	MESSAGE_FAILED_TOKEN_NOT_VALID    = "token not valid"
 	MESSAGE_FAILED_TOKEN_NOT_FOUND    = "token not found"
+	MESSAGE_FAILED_TOKEN_REVOKED      = "token revoked"
 	MESSAGE_FAILED_GET_USER           = "failed get user"
 	MESSAGE_FAILED_LOGIN              = "failed login"
 	MESSAGE_FAILED_UPDATE_USER        = "failed update user"
 	MESSAGE_FAILED_DELETE_USER        = "failed delete user"
-	MESSAGE_FAILED_PROCESS_REQUEST     = "failed process request"
+	MESSAGE_FAILED_PROCESS_REQUEST    = "failed process request"
 	MESSAGE_FAILED_DENIED_ACCESS      = "denied access"
 	MESSAGE_FAILED_VERIFY_EMAIL       = "failed verify email"
+	MESSAGE_FAILED_RESTORE_USER       = "failed restore user"
+	MESSAGE_FAILED_ERASE_USER         = "failed erase user"
 
 	// Success
 	MESSAGE_SUCCESS_REGISTER_USER           = "success create user"
@@ -32,6 +35,8 @@ const (
 	MESSAGE_SUCCESS_DELETE_USER             = "success delete user"
 	MESSAGE_SEND_VERIFICATION_EMAIL_SUCCESS = "success send verification email"
 	MESSAGE_SUCCESS_VERIFY_EMAIL            = "success verify email"
+	MESSAGE_SUCCESS_RESTORE_USER            = "success restore user"
+	MESSAGE_SUCCESS_ERASE_USER              = "success erase user"
 )
 
 var (
@@ -41,6 +46,7 @@ var (
 	ErrEmailAlreadyExists     = errors.New("email already exist")
 	ErrUpdateUser             = errors.New("failed to update user")
 	ErrUserNotFound           = errors.New("user not found")
+	ErrUserNotDeleted         = errors.New("user not found among deleted users")
 	ErrEmailNotFound          = errors.New("email not found")
 	ErrDeleteUser             = errors.New("failed to delete user")
 	ErrTokenInvalid           = errors.New("token invalid")
@@ -83,6 +89,17 @@ type (
 		Email      string `json:"email" form:"email" binding:"omitempty,email"`
 	}
 
+	// UserAdminUpdateRequest is accepted from admins only; Role additionally
+	// requires the role:manage permission and Email only takes effect once
+	// confirmed from the new address
+	UserAdminUpdateRequest struct {
+		Name       string `json:"name" form:"name" binding:"omitempty,min=2,max=100"`
+		TelpNumber string `json:"telp_number" form:"telp_number" binding:"omitempty,min=8,max=20"`
+		Email      string `json:"email" form:"email" binding:"omitempty,email"`
+		Role       string `json:"role" form:"role" binding:"omitempty,max=50"`
+		IsVerified *bool  `json:"is_verified" form:"is_verified"`
+	}
+
 	UserUpdateResponse struct {
 		ID         string `json:"id"`
 		Name       string `json:"name"`
@@ -106,7 +123,8 @@ type (
 	}
 
 	UserLoginRequest struct {
-		Email    string `json:"email" form:"email" binding:"required"`
-		Password string `json:"password" form:"password" binding:"required"`
+		Email       string `json:"email" form:"email" binding:"required"`
+		Password    string `json:"password" form:"password" binding:"required"`
+		DeviceLabel string `json:"device_label" form:"device_label" binding:"omitempty,max=100"`
 	}
-)
\ No newline at end of file
+)
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"context"
+
+	authService "blog/modules/auth/service"
+	rbacService "blog/modules/rbac/service"
+	"blog/pkg/constants"
+)
+
+// UserPolicy decides whether the authenticated user may act on a user record.
+// Everyone may act on their own record, acting on others takes a permission.
+type UserPolicy interface {
+	CanUpdate(ctx context.Context, actor *authService.JWTCustomClaim, userId string) (bool, error)
+	CanDelete(ctx context.Context, actor *authService.JWTCustomClaim, userId string) (bool, error)
+	CanAssignRole(ctx context.Context, actor *authService.JWTCustomClaim) (bool, error)
+}
+
+type userPolicy struct {
+	rbacService rbacService.RBACService
+}
+
+func NewUserPolicy(rbacService rbacService.RBACService) UserPolicy {
+	return &userPolicy{
+		rbacService: rbacService,
+	}
+}
+
+func (p *userPolicy) CanUpdate(ctx context.Context, actor *authService.JWTCustomClaim, userId string) (bool, error) {
+	return p.ownerOr(ctx, actor, userId, constants.ENUM_PERMISSION_USER_UPDATE)
+}
+
+func (p *userPolicy) CanDelete(ctx context.Context, actor *authService.JWTCustomClaim, userId string) (bool, error) {
+	return p.ownerOr(ctx, actor, userId, constants.ENUM_PERMISSION_USER_DELETE)
+}
+
+func (p *userPolicy) CanAssignRole(ctx context.Context, actor *authService.JWTCustomClaim) (bool, error) {
+	if actor == nil || !actor.HasScopes(constants.ENUM_PERMISSION_ROLE_MANAGE) {
+		return false, nil
+	}
+
+	return p.rbacService.HasPermissions(ctx, actor.Role, constants.ENUM_PERMISSION_ROLE_MANAGE)
+}
+
+func (p *userPolicy) ownerOr(
+	ctx context.Context,
+	actor *authService.JWTCustomClaim,
+	userId string,
+	permission string,
+) (bool, error) {
+	// A scoped API key needs the permission even for the user's own record
+	if actor == nil || !actor.HasScopes(permission) {
+		return false, nil
+	}
+
+	if actor.UserID == userId {
+		return true, nil
+	}
+
+	return p.rbacService.HasPermissions(ctx, actor.Role, permission)
+}
@@ -12,7 +12,10 @@ type User struct {
 	TelpNumber string `json:"telp_number"`
 	Role       string `json:"role"`
 	ImageUrl   string `json:"image_url"`
+	AvatarKey  string `json:"-"`
 	IsVerified bool   `json:"is_verified"`
+	// DeletedAt keeps soft deleted users out of the list
+	DeletedAt gorm.DeletedAt `json:"-"`
 }
 
 type UserFilter struct {
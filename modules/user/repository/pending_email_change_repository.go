@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"blog/database/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PendingEmailChangeRepository interface {
+	Replace(ctx context.Context, tx *gorm.DB, change entities.PendingEmailChange) error
+	Consume(ctx context.Context, tx *gorm.DB, tokenHash string) (entities.PendingEmailChange, error)
+}
+
+type pendingEmailChangeRepository struct {
+	db *gorm.DB
+}
+
+func NewPendingEmailChangeRepository(db *gorm.DB) PendingEmailChangeRepository {
+	return &pendingEmailChangeRepository{
+		db: db,
+	}
+}
+
+// Replace drops the pending change of the user, if any, and stores the new one
+func (r *pendingEmailChangeRepository) Replace(ctx context.Context, tx *gorm.DB, change entities.PendingEmailChange) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Where("user_id = ?", change.UserID).Delete(&entities.PendingEmailChange{}).Error; err != nil {
+		return err
+	}
+
+	return tx.WithContext(ctx).Create(&change).Error
+}
+
+// Consume deletes an unexpired change and returns it in a single statement,
+// so a link can never be confirmed twice
+func (r *pendingEmailChangeRepository) Consume(ctx context.Context, tx *gorm.DB, tokenHash string) (entities.PendingEmailChange, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var change entities.PendingEmailChange
+	result := tx.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND expires_at > ?", tokenHash, time.Now()).
+		Delete(&change)
+	if result.Error != nil {
+		return entities.PendingEmailChange{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entities.PendingEmailChange{}, gorm.ErrRecordNotFound
+	}
+
+	return change, nil
+}
@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"blog/database/entities"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
@@ -14,7 +16,13 @@ type (
 		GetUserByEmail(ctx context.Context, tx *gorm.DB, email string) (entities.User, error)
 		CheckEmail(ctx context.Context, tx *gorm.DB, email string) (entities.User, bool, error)
 		Update(ctx context.Context, tx *gorm.DB, user entities.User) (entities.User, error)
+		UpdateColumns(ctx context.Context, tx *gorm.DB, userId string, columns map[string]any) error
+		UpdateEmail(ctx context.Context, tx *gorm.DB, userId string, email string, isVerified bool) error
+		UpdateAvatarKey(ctx context.Context, tx *gorm.DB, userId string, previousKey string, key string) error
 		Delete(ctx context.Context, tx *gorm.DB, userId string) error
+		Restore(ctx context.Context, tx *gorm.DB, userId string) error
+		FindDeletedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entities.User, error)
+		Erase(ctx context.Context, tx *gorm.DB, userId string) (entities.User, error)
 	}
 
 	userRepository struct {
@@ -66,13 +74,15 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, tx *gorm.DB, email
 	return user, nil
 }
 
+// CheckEmail includes deleted users, their address stays taken until the
+// account is erased
 func (r *userRepository) CheckEmail(ctx context.Context, tx *gorm.DB, email string) (entities.User, bool, error) {
 	if tx == nil {
 		tx = r.db
 	}
 
 	var user entities.User
-	if err := tx.WithContext(ctx).Where("email = ?", email).Take(&user).Error; err != nil {
+	if err := tx.WithContext(ctx).Unscoped().Where("email = ?", email).Take(&user).Error; err != nil {
 		return entities.User{}, false, err
 	}
 
@@ -91,6 +101,76 @@ func (r *userRepository) Update(ctx context.Context, tx *gorm.DB, user entities.
 	return user, nil
 }
 
+// UpdateColumns updates the given columns as is, including zero values that
+// Update would skip
+func (r *userRepository) UpdateColumns(ctx context.Context, tx *gorm.DB, userId string, columns map[string]any) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if err := tx.WithContext(ctx).Model(&entities.User{}).Where("id = ?", userId).Updates(columns).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateAvatarKey points the user at a new avatar and clears ImageUrl, only
+// while the avatar is still previousKey. It returns gorm.ErrRecordNotFound
+// when another upload got there first.
+func (r *userRepository) UpdateAvatarKey(ctx context.Context, tx *gorm.DB, userId string, previousKey string, key string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.User{}).
+		Where("id = ? AND COALESCE(avatar_key, '') = ?", userId, previousKey).
+		Updates(map[string]any{
+			"image_url":  "",
+			"avatar_key": key,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UpdateEmail sets the address of a user. When another user holds it the
+// unique index rejects the update and gorm.ErrDuplicatedKey is returned, so
+// two users racing for one address can not both get it.
+func (r *userRepository) UpdateEmail(ctx context.Context, tx *gorm.DB, userId string, email string, isVerified bool) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&entities.User{}).
+		Where("id = ?", userId).
+		Updates(map[string]any{"email": email, "is_verified": isVerified})
+	if result.Error != nil {
+		if translator, ok := tx.Dialector.(gorm.ErrorTranslator); ok {
+			return translator.Translate(result.Error)
+		}
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 func (r *userRepository) Delete(ctx context.Context, tx *gorm.DB, userId string) error {
 	if tx == nil {
 		tx = r.db
@@ -101,4 +181,74 @@ func (r *userRepository) Delete(ctx context.Context, tx *gorm.DB, userId string)
 	}
 
 	return nil
+}
+
+// Restore undoes a soft delete
+func (r *userRepository) Restore(ctx context.Context, tx *gorm.DB, userId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).
+		Unscoped().
+		Model(&entities.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", userId).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// FindDeletedBefore returns users soft deleted before the given time, oldest first
+func (r *userRepository) FindDeletedBefore(
+	ctx context.Context,
+	tx *gorm.DB,
+	before time.Time,
+	limit int,
+) ([]entities.User, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var users []entities.User
+	if err := tx.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Erase removes the row for good, whether deleted or not. Every table holding
+// data of the user cascades on it. The erased row is returned.
+func (r *userRepository) Erase(ctx context.Context, tx *gorm.DB, userId string) (entities.User, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var user entities.User
+	result := tx.WithContext(ctx).
+		Unscoped().
+		Clauses(clause.Returning{}).
+		Where("id = ?", userId).
+		Delete(&user)
+	if result.Error != nil {
+		return entities.User{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entities.User{}, gorm.ErrRecordNotFound
+	}
+
+	return user, nil
 }
\ No newline at end of file
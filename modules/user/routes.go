@@ -2,7 +2,9 @@ package user
 
 import (
 	"blog/middlewares"
+	apiKeyService "blog/modules/apikey/service"
 	"blog/modules/auth/service"
+	rbacService "blog/modules/rbac/service"
 	"blog/modules/user/controller"
 	"blog/pkg/constants"
 	"github.com/gin-gonic/gin"
@@ -11,18 +13,40 @@ import (
 
 func RegisterRoutes(server *gin.Engine, injector *do.Injector) {
 	userController := do.MustInvoke[controller.UserController](injector)
+	emailChangeController := do.MustInvoke[controller.EmailChangeController](injector)
+	dataExportController := do.MustInvoke[controller.DataExportController](injector)
+	avatarController := do.MustInvoke[controller.AvatarController](injector)
 	jwtService := do.MustInvokeNamed[service.JWTService](injector, constants.JWTService)
+	revocationService := do.MustInvokeNamed[service.TokenRevocationService](injector, constants.TokenRevocationService)
+	rbacService := do.MustInvokeNamed[rbacService.RBACService](injector, constants.RBACService)
+	apiKeyService := do.MustInvokeNamed[apiKeyService.APIKeyService](injector, constants.APIKeyService)
 
 	userRoutes := server.Group("/api/user")
 	{
 		userRoutes.POST("", userController.Register)
-		userRoutes.POST("/login", userController.Login)
-		userRoutes.GET("", userController.GetAllUser)
-		userRoutes.GET("/me", middlewares.Authenticate(jwtService), userController.Me)
-		userRoutes.PUT("/:id", middlewares.Authenticate(jwtService), userController.Update)
-		userRoutes.DELETE("/:id", middlewares.Authenticate(jwtService), userController.Delete)
-		userRoutes.POST("/send-verification-email", userController.SendVerificationEmail)
-		userRoutes.POST("/verify-email", userController.VerifyEmail)
-		userRoutes.POST("/refresh", middlewares.Authenticate(jwtService), userController.Refresh)
+		userRoutes.POST("/login", middlewares.Deprecated("/api/v1/auth/login"), userController.Login)
+		userRoutes.GET(
+			"",
+			middlewares.AuthenticateWithAPIKey(jwtService, revocationService, apiKeyService),
+			middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_USER_LIST),
+			userController.GetAllUser,
+		)
+		userRoutes.GET("/me", middlewares.AuthenticateWithAPIKey(jwtService, revocationService, apiKeyService), userController.Me)
+		userRoutes.PUT("/me/avatar", middlewares.Authenticate(jwtService, revocationService), avatarController.Upload)
+		userRoutes.GET("/me/export", middlewares.Authenticate(jwtService, revocationService), dataExportController.Export)
+		userRoutes.POST("/me/email", middlewares.Authenticate(jwtService, revocationService), emailChangeController.RequestChange)
+		userRoutes.POST("/email/confirm", emailChangeController.ConfirmChange)
+		userRoutes.PUT("/:id", middlewares.AuthenticateWithAPIKey(jwtService, revocationService, apiKeyService), userController.Update)
+		userRoutes.DELETE("/:id", middlewares.AuthenticateWithAPIKey(jwtService, revocationService, apiKeyService), userController.Delete)
+		userRoutes.POST("/send-verification-email", middlewares.Deprecated("/api/v1/auth/send-verification-email"), userController.SendVerificationEmail)
+		userRoutes.POST("/verify-email", middlewares.Deprecated("/api/v1/auth/verify-email"), userController.VerifyEmail)
+		userRoutes.POST("/refresh", middlewares.Deprecated("/api/v1/auth/refresh"), userController.Refresh)
 	}
-}
\ No newline at end of file
+
+	adminRoutes := server.Group("/api/v1/admin/users", middlewares.AuthenticateWithAPIKey(jwtService, revocationService, apiKeyService))
+	{
+		adminRoutes.PUT("/:id", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_USER_UPDATE), userController.AdminUpdate)
+		adminRoutes.POST("/:id/restore", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_USER_DELETE), userController.Restore)
+		adminRoutes.DELETE("/:id/erase", middlewares.RequirePermission(rbacService, constants.ENUM_PERMISSION_USER_DELETE), userController.Erase)
+	}
+}
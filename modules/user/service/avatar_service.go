@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"blog/config"
+	"blog/modules/user/dto"
+	"blog/modules/user/repository"
+	"blog/pkg/imaging"
+	"blog/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// avatarSize is the side length of the avatar behind ImageUrl, the
+// thumbnails are stored next to it with their size in the key
+const avatarSize = 512
+
+var avatarThumbnailSizes = []int{128, 64}
+
+type AvatarService interface {
+	Upload(ctx context.Context, userId string, file *multipart.FileHeader) (dto.AvatarResponse, error)
+	// URL is where clients fetch the avatar from, a signed URL for uploads and
+	// imageUrl as it is otherwise
+	URL(ctx context.Context, imageUrl string, avatarKey string) string
+	// Delete removes every size of the uploaded avatar at avatarKey
+	Delete(ctx context.Context, avatarKey string) error
+}
+
+type avatarService struct {
+	userRepository repository.UserRepository
+	storage        storage.Storage
+	config         *config.AvatarConfig
+	db             *gorm.DB
+}
+
+func NewAvatarService(
+	userRepo repository.UserRepository,
+	storage storage.Storage,
+	cfg *config.AvatarConfig,
+	db *gorm.DB,
+) AvatarService {
+	return &avatarService{
+		userRepository: userRepo,
+		storage:        storage,
+		config:         cfg,
+		db:             db,
+	}
+}
+
+// Upload replaces the avatar of the user. The image is decoded and encoded
+// again at fixed sizes, so nothing but its pixels is ever stored.
+func (s *avatarService) Upload(ctx context.Context, userId string, file *multipart.FileHeader) (dto.AvatarResponse, error) {
+	if file.Size > int64(s.config.MaxSize) {
+		return dto.AvatarResponse{}, dto.ErrAvatarTooLarge
+	}
+
+	data, err := readUpload(file, s.config.MaxSize)
+	if err != nil {
+		return dto.AvatarResponse{}, err
+	}
+
+	img, err := imaging.Decode(data, imaging.Limits{
+		MinDimension: s.config.MinDimension,
+		MaxDimension: s.config.MaxDimension,
+	})
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooLarge) || errors.Is(err, imaging.ErrTooSmall) {
+			return dto.AvatarResponse{}, dto.ErrAvatarDimensions
+		}
+		return dto.AvatarResponse{}, dto.ErrAvatarUnsupportedType
+	}
+
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.AvatarResponse{}, dto.ErrUserNotFound
+	}
+
+	encoded := make(map[int][]byte, len(avatarThumbnailSizes)+1)
+	for _, size := range append([]int{avatarSize}, avatarThumbnailSizes...) {
+		var buf bytes.Buffer
+		if err := img.Encode(&buf, img.Square(size)); err != nil {
+			return dto.AvatarResponse{}, err
+		}
+		encoded[size] = buf.Bytes()
+	}
+
+	// Named after the content, so uploading the current avatar again ends up
+	// on the same key and nothing may be deleted
+	digest := sha256.Sum256(encoded[avatarSize])
+	key := storage.ContentKey("avatars/"+userId, digest[:], img.Extension())
+	replaced := user.AvatarKey != "" && user.AvatarKey != key
+
+	stored := make([]string, 0, len(encoded))
+	// Files another upload of the same image now points at stay in place
+	rollback := func() {
+		if key == user.AvatarKey {
+			return
+		}
+		if current, err := s.userRepository.GetUserById(ctx, s.db, userId); err == nil && current.AvatarKey == key {
+			return
+		}
+		s.deleteFiles(ctx, stored)
+	}
+
+	for size, sizeKey := range avatarVariantKeys(key) {
+		if err := s.storage.Put(ctx, sizeKey, bytes.NewReader(encoded[size]), img.ContentType()); err != nil {
+			rollback()
+			return dto.AvatarResponse{}, err
+		}
+		stored = append(stored, sizeKey)
+	}
+
+	// Signed URLs expire, so only the key is kept and ImageUrl is cleared of
+	// any URL set before. The update only goes through while the avatar read
+	// above is still current, so of two concurrent uploads the loser removes
+	// its own files and the winner removes the old ones.
+	err = s.userRepository.UpdateAvatarKey(ctx, s.db, userId, user.AvatarKey, key)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		rollback()
+		return dto.AvatarResponse{}, dto.ErrAvatarChanged
+	}
+	if err != nil {
+		rollback()
+		return dto.AvatarResponse{}, dto.ErrUpdateUser
+	}
+
+	// The new avatar is in place, leftovers of the old one are only logged
+	if replaced {
+		if err := s.Delete(ctx, user.AvatarKey); err != nil {
+			log.Println(err)
+		}
+	}
+
+	thumbnails := make(map[string]string, len(avatarThumbnailSizes))
+	for size, sizeKey := range avatarVariantKeys(key) {
+		if size != avatarSize {
+			thumbnails[strconv.Itoa(size)] = s.URL(ctx, "", sizeKey)
+		}
+	}
+
+	return dto.AvatarResponse{ImageUrl: s.URL(ctx, "", key), Thumbnails: thumbnails}, nil
+}
+
+func (s *avatarService) URL(ctx context.Context, imageUrl string, avatarKey string) string {
+	if avatarKey == "" {
+		return imageUrl
+	}
+
+	url, err := s.storage.SignedURL(ctx, avatarKey, s.config.URLExpiry)
+	if err != nil {
+		log.Println(err)
+		return ""
+	}
+
+	return url
+}
+
+func (s *avatarService) Delete(ctx context.Context, avatarKey string) error {
+	var errs []error
+	for _, sizeKey := range avatarVariantKeys(avatarKey) {
+		if err := s.storage.Delete(ctx, sizeKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *avatarService) deleteFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// readUpload reads at most maxSize bytes, the size in the multipart header is
+// what the client claimed
+func readUpload(file *multipart.FileHeader, maxSize int) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, dto.ErrAvatarTooLarge
+	}
+
+	return data, nil
+}
+
+// avatarVariantKeys maps every stored size of the avatar at key to its
+// storage key, "<name>.png" becomes "<name>_128.png" for a thumbnail
+func avatarVariantKeys(key string) map[int]string {
+	keys := map[int]string{avatarSize: key}
+
+	base, ext := key, ""
+	if dot := strings.LastIndex(key, "."); dot > strings.LastIndex(key, "/") {
+		base, ext = key[:dot], key[dot:]
+	}
+	for _, size := range avatarThumbnailSizes {
+		keys[size] = base + "_" + strconv.Itoa(size) + ext
+	}
+
+	return keys
+}
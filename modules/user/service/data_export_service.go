@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"blog/config"
+	"blog/database/entities"
+	authDto "blog/modules/auth/dto"
+	"blog/modules/user/dto"
+	"blog/modules/user/repository"
+	"blog/pkg/cache"
+	"blog/pkg/export"
+	"blog/pkg/helpers"
+	"blog/pkg/storage"
+	"blog/pkg/utils"
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+const (
+	dataExportKeyPrefix     = "exports/"
+	dataExportRateKeyPrefix = "data_export:user:"
+)
+
+// DataExportService builds an archive of everything stored about a user from
+// the sections of every registered exporter
+type DataExportService interface {
+	Export(ctx context.Context, userId string) (dto.DataExportResult, error)
+	DeleteExpired(ctx context.Context) error
+	DeleteUserExports(ctx context.Context, userId string) error
+}
+
+type dataExportService struct {
+	injector       *do.Injector
+	userRepository repository.UserRepository
+	storage        storage.Storage
+	config         *config.DataExportConfig
+	limiter        *cache.Limiter
+	db             *gorm.DB
+}
+
+func NewDataExportService(
+	injector *do.Injector,
+	userRepo repository.UserRepository,
+	fileStorage storage.Storage,
+	cfg *config.DataExportConfig,
+	store cache.Store,
+	db *gorm.DB,
+) DataExportService {
+	s := &dataExportService{
+		injector:       injector,
+		userRepository: userRepo,
+		storage:        fileStorage,
+		config:         cfg,
+		limiter:        cache.NewLimiter(store),
+		db:             db,
+	}
+
+	if cfg.SweepInterval > 0 {
+		go s.sweepExpired(cfg.SweepInterval)
+	}
+
+	return s
+}
+
+// NewProfileExporter exports the user row itself
+func NewProfileExporter(userRepo repository.UserRepository, db *gorm.DB) export.Exporter {
+	return export.NewExporter(
+		"profile",
+		func(context.Context, string) (int64, error) {
+			return 1, nil
+		},
+		func(ctx context.Context, userId string) ([]export.Record, error) {
+			user, err := userRepo.GetUserById(ctx, db, userId)
+			if err != nil {
+				return nil, err
+			}
+			return export.ToRecords(user)
+		},
+	)
+}
+
+// Export sizes the export up by counting its records first. Up to the inline
+// limit the archive is built and returned right away; a larger export is
+// collected, archived and emailed as a link in the background.
+func (s *dataExportService) Export(ctx context.Context, userId string) (dto.DataExportResult, error) {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.DataExportResult{}, dto.ErrUserNotFound
+	}
+
+	retryAfter, err := s.limiter.Allow(ctx, dataExportRateKeyPrefix+userId, s.config.MaxPerUser, s.config.RateWindow)
+	if err != nil {
+		return dto.DataExportResult{}, err
+	}
+	if retryAfter > 0 {
+		return dto.DataExportResult{}, &authDto.RetryAfterError{Err: dto.ErrTooManyDataExports, RetryAfter: retryAfter}
+	}
+
+	exporters, err := export.Exporters(s.injector)
+	if err != nil {
+		return dto.DataExportResult{}, err
+	}
+
+	records, err := export.Count(ctx, exporters, userId)
+	if err != nil {
+		return dto.DataExportResult{}, err
+	}
+
+	exportId, err := helpers.GenerateRandomToken(16)
+	if err != nil {
+		return dto.DataExportResult{}, err
+	}
+	filename := fmt.Sprintf("export-%s-%s.zip", time.Now().UTC().Format("20060102150405"), exportId)
+
+	if records > int64(s.config.InlineMaxRecords) {
+		go func() {
+			if err := s.exportInBackground(user, dataExportKeyPrefix+userId+"/"+filename, exporters); err != nil {
+				log.Println(err)
+			}
+		}()
+
+		return dto.DataExportResult{Queued: &dto.DataExportQueuedResponse{ID: exportId}}, nil
+	}
+
+	archive, err := s.build(ctx, userId, exporters)
+	if err != nil {
+		return dto.DataExportResult{}, err
+	}
+
+	return dto.DataExportResult{Archive: archive, Filename: filename}, nil
+}
+
+func (s *dataExportService) build(ctx context.Context, userId string, exporters []export.Exporter) ([]byte, error) {
+	sections, err := export.Collect(ctx, exporters, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return export.Archive(sections)
+}
+
+// exportInBackground outlives the request. The archive only stays in the
+// storage as long as the link works, DeleteExpired removes it afterwards.
+func (s *dataExportService) exportInBackground(user entities.User, key string, exporters []export.Exporter) error {
+	ctx := context.Background()
+
+	archive, err := s.build(ctx, user.ID.String(), exporters)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.Put(ctx, key, bytes.NewReader(archive), "application/zip"); err != nil {
+		return err
+	}
+
+	link, err := s.storage.SignedURL(ctx, key, s.config.Expiry)
+	if err != nil {
+		return err
+	}
+
+	return s.sendExportReadyEmail(user, link)
+}
+
+// DeleteExpired removes the archives whose link has expired. It goes by the
+// storage alone, so archives left behind by a restart are removed as well.
+func (s *dataExportService) DeleteExpired(ctx context.Context) error {
+	objects, err := s.storage.List(ctx, dataExportKeyPrefix)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-s.config.Expiry)
+	var errs []error
+	for _, object := range objects {
+		if !object.LastModified.Before(cutoff) {
+			continue
+		}
+		if err := s.storage.Delete(ctx, object.Key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DeleteUserExports removes every archive of the user, expired or not, when
+// the account is erased
+func (s *dataExportService) DeleteUserExports(ctx context.Context, userId string) error {
+	objects, err := s.storage.List(ctx, dataExportKeyPrefix+userId+"/")
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, object := range objects {
+		if err := s.storage.Delete(ctx, object.Key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// sweepExpired runs DeleteExpired on every tick, independent of whether
+// deleted accounts are being erased
+func (s *dataExportService) sweepExpired(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.DeleteExpired(context.Background()); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (s *dataExportService) sendExportReadyEmail(user entities.User, link string) error {
+	body, err := utils.RenderMailTemplate("data_export_ready", map[string]string{
+		"Name":   user.Name,
+		"Link":   link,
+		"Expiry": s.config.Expiry.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return utils.SendMail(user.Email, "Your Data Export Is Ready", body)
+}
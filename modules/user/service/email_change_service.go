@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/database/entities"
+	authDto "blog/modules/auth/dto"
+	authRepo "blog/modules/auth/repository"
+	authService "blog/modules/auth/service"
+	"blog/modules/user/dto"
+	"blog/modules/user/repository"
+	"blog/pkg/helpers"
+	"blog/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// EmailChangeService moves a user to a new address only once they proved
+// they own it
+type EmailChangeService interface {
+	RequestChange(ctx context.Context, userId string, ip string, req dto.EmailChangeRequest) error
+	AdminRequestChange(ctx context.Context, userId string, newEmail string) error
+	ConfirmChange(ctx context.Context, req dto.ConfirmEmailChangeRequest) (dto.EmailChangeResponse, error)
+}
+
+type emailChangeService struct {
+	userRepository               repository.UserRepository
+	pendingEmailChangeRepository repository.PendingEmailChangeRepository
+	refreshTokenRepository       authRepo.RefreshTokenRepository
+	revocationService            authService.TokenRevocationService
+	loginThrottleService         authService.LoginThrottleService
+	config                       *config.EmailChangeConfig
+	db                           *gorm.DB
+}
+
+func NewEmailChangeService(
+	userRepo repository.UserRepository,
+	pendingEmailChangeRepo repository.PendingEmailChangeRepository,
+	refreshTokenRepo authRepo.RefreshTokenRepository,
+	revocationService authService.TokenRevocationService,
+	loginThrottleService authService.LoginThrottleService,
+	cfg *config.EmailChangeConfig,
+	db *gorm.DB,
+) EmailChangeService {
+	return &emailChangeService{
+		userRepository:               userRepo,
+		pendingEmailChangeRepository: pendingEmailChangeRepo,
+		refreshTokenRepository:       refreshTokenRepo,
+		revocationService:            revocationService,
+		loginThrottleService:         loginThrottleService,
+		config:                       cfg,
+		db:                           db,
+	}
+}
+
+// RequestChange checks the current password, so an access token alone can
+// not move the account, and starts the change. Guessing the password is
+// throttled like a login.
+func (s *emailChangeService) RequestChange(ctx context.Context, userId string, ip string, req dto.EmailChangeRequest) error {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.ErrUserNotFound
+	}
+
+	if err := s.loginThrottleService.Attempt(ctx, user.Email, ip); err != nil {
+		return err
+	}
+
+	if isValid, _, _ := helpers.CheckPassword(user.Password, []byte(req.CurrentPassword)); !isValid {
+		if err := s.loginThrottleService.RecordFailure(ctx, user.Email, ip); err != nil {
+			return err
+		}
+		return authDto.ErrCurrentPassword
+	}
+
+	if err := s.loginThrottleService.RecordSuccess(ctx, user.Email, ip); err != nil {
+		return err
+	}
+
+	return s.requestChange(ctx, user, req.NewEmail)
+}
+
+// AdminRequestChange starts a change on behalf of the user, the new address
+// still has to be confirmed by its owner
+func (s *emailChangeService) AdminRequestChange(ctx context.Context, userId string, newEmail string) error {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
+	if err != nil {
+		return dto.ErrUserNotFound
+	}
+
+	return s.requestChange(ctx, user, newEmail)
+}
+
+// requestChange emails a notice to the current address and then a
+// confirmation link to the new one. Asking again replaces the previous request.
+func (s *emailChangeService) requestChange(ctx context.Context, user entities.User, newEmail string) error {
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return dto.ErrEmailUnchanged
+	}
+
+	_, exists, err := s.userRepository.CheckEmail(ctx, s.db, newEmail)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if exists {
+		return dto.ErrEmailAlreadyExists
+	}
+
+	token, err := helpers.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := s.pendingEmailChangeRepository.Replace(ctx, s.db, entities.PendingEmailChange{
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		TokenHash: helpers.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.Expiry),
+	}); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.config.URL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	// The owner hears of the request first, no confirmation link goes out
+	// unless the notice did
+	noticeBody, err := utils.RenderMailTemplate("email_change_notice", map[string]string{
+		"Name":     user.Name,
+		"NewEmail": newEmail,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := utils.SendMail(user.Email, "Email Change Requested", noticeBody); err != nil {
+		return err
+	}
+
+	confirmBody, err := utils.RenderMailTemplate("email_change_confirm", map[string]string{
+		"Name":   user.Name,
+		"Email":  newEmail,
+		"Link":   link.String(),
+		"Expiry": s.config.Expiry.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return utils.SendMail(newEmail, "Confirm Your New Email", confirmBody)
+}
+
+// ConfirmChange swaps the address and signs the user out everywhere, the
+// new address counts as verified since the link was opened from it
+func (s *emailChangeService) ConfirmChange(
+	ctx context.Context,
+	req dto.ConfirmEmailChangeRequest,
+) (dto.EmailChangeResponse, error) {
+	var change entities.PendingEmailChange
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		consumed, err := s.pendingEmailChangeRepository.Consume(ctx, tx, helpers.HashToken(req.Token))
+		if err != nil {
+			return dto.ErrEmailChangeToken
+		}
+		change = consumed
+
+		// Taken since the request was made, the unique index below covers a
+		// user registering it concurrently
+		_, exists, err := s.userRepository.CheckEmail(ctx, tx, change.NewEmail)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if exists {
+			return dto.ErrEmailAlreadyExists
+		}
+
+		if err := s.userRepository.UpdateEmail(ctx, tx, change.UserID.String(), change.NewEmail, true); err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return dto.ErrEmailAlreadyExists
+			}
+			return err
+		}
+
+		return s.refreshTokenRepository.DeleteByUserID(ctx, tx, change.UserID.String())
+	})
+	if err != nil {
+		return dto.EmailChangeResponse{}, err
+	}
+
+	if err := s.revocationService.RevokeUserTokens(ctx, change.UserID.String()); err != nil {
+		return dto.EmailChangeResponse{}, err
+	}
+
+	return dto.EmailChangeResponse{
+		Email:      change.NewEmail,
+		IsVerified: true,
+	}, nil
+}
@@ -2,85 +2,72 @@ package service
 
 import (
 	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
 
-	"blog/database/entities"
-	authDto "blog/modules/auth/dto"
+	"blog/config"
 	authRepo "blog/modules/auth/repository"
 	authService "blog/modules/auth/service"
+	rbacService "blog/modules/rbac/service"
 	"blog/modules/user/dto"
 	"blog/modules/user/repository"
-	"blog/pkg/constants"
-	"blog/pkg/helpers"
-	"blog/pkg/utils"
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type UserService interface {
-	Register(ctx context.Context, req dto.UserCreateRequest) (dto.UserResponse, error)
 	GetUserById(ctx context.Context, userId string) (dto.UserResponse, error)
-	Verify(ctx context.Context, req dto.UserLoginRequest) (authDto.TokenResponse, error)
-	SendVerificationEmail(ctx context.Context, req dto.SendVerificationEmailRequest) error
-	VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (dto.VerifyEmailResponse, error)
 	Update(ctx context.Context, req dto.UserUpdateRequest, userId string) (dto.UserUpdateResponse, error)
+	AdminUpdate(ctx context.Context, req dto.UserAdminUpdateRequest, userId string) (dto.UserUpdateResponse, error)
 	Delete(ctx context.Context, userId string) error
-	RefreshToken(ctx context.Context, req authDto.RefreshTokenRequest) (authDto.TokenResponse, error)
+	Restore(ctx context.Context, userId string) (dto.UserResponse, error)
+	Erase(ctx context.Context, userId string) error
 }
 
+// erasureBatchSize bounds how many users one erasure run loads at once
+const erasureBatchSize = 100
+
 type userService struct {
 	userRepository         repository.UserRepository
 	refreshTokenRepository authRepo.RefreshTokenRepository
-	jwtService             authService.JWTService
+	revocationService      authService.TokenRevocationService
+	rbacService            rbacService.RBACService
+	emailChangeService     EmailChangeService
+	avatarService          AvatarService
+	dataExportService      DataExportService
+	deletionConfig         *config.UserDeletionConfig
 	db                     *gorm.DB
 }
 
 func NewUserService(
 	userRepo repository.UserRepository,
 	refreshTokenRepo authRepo.RefreshTokenRepository,
-	jwtService authService.JWTService,
+	revocationService authService.TokenRevocationService,
+	rbacService rbacService.RBACService,
+	emailChangeService EmailChangeService,
+	avatarService AvatarService,
+	dataExportService DataExportService,
+	deletionConfig *config.UserDeletionConfig,
 	db *gorm.DB,
 ) UserService {
-	return &userService{
+	s := &userService{
 		userRepository:         userRepo,
 		refreshTokenRepository: refreshTokenRepo,
-		jwtService:             jwtService,
+		revocationService:      revocationService,
+		rbacService:            rbacService,
+		emailChangeService:     emailChangeService,
+		avatarService:          avatarService,
+		dataExportService:      dataExportService,
+		deletionConfig:         deletionConfig,
 		db:                     db,
 	}
-}
 
-func (s *userService) Register(ctx context.Context, req dto.UserCreateRequest) (dto.UserResponse, error) {
-	_, exists, err := s.userRepository.CheckEmail(ctx, s.db, req.Email)
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return dto.UserResponse{}, err
-	}
-	if exists {
-		return dto.UserResponse{}, dto.ErrEmailAlreadyExists
-	}
-
-	user := entities.User{
-		ID:         uuid.New(),
-		Name:       req.Name,
-		Email:      req.Email,
-		TelpNumber: req.TelpNumber,
-		Password:   req.Password,
-		Role:       constants.ENUM_ROLE_USER,
-		IsVerified: false,
-	}
-
-	createdUser, err := s.userRepository.Register(ctx, s.db, user)
-	if err != nil {
-		return dto.UserResponse{}, err
+	if deletionConfig.ErasureInterval > 0 {
+		go s.eraseDeletedUsers(deletionConfig.ErasureInterval)
 	}
 
-	return dto.UserResponse{
-		ID:         createdUser.ID.String(),
-		Name:       createdUser.Name,
-		Email:      createdUser.Email,
-		TelpNumber: createdUser.TelpNumber,
-		Role:       createdUser.Role,
-		ImageUrl:   createdUser.ImageUrl,
-		IsVerified: createdUser.IsVerified,
-	}, nil
+	return s
 }
 
 func (s *userService) GetUserById(ctx context.Context, userId string) (dto.UserResponse, error) {
@@ -95,109 +82,106 @@ func (s *userService) GetUserById(ctx context.Context, userId string) (dto.UserR
 		Email:      user.Email,
 		TelpNumber: user.TelpNumber,
 		Role:       user.Role,
-		ImageUrl:   user.ImageUrl,
+		ImageUrl:   s.avatarService.URL(ctx, user.ImageUrl, user.AvatarKey),
 		IsVerified: user.IsVerified,
 	}, nil
 }
 
-func (s *userService) Verify(ctx context.Context, req dto.UserLoginRequest) (authDto.TokenResponse, error) {
-	user, err := s.userRepository.GetUserByEmail(ctx, s.db, req.Email)
+func (s *userService) Update(ctx context.Context, req dto.UserUpdateRequest, userId string) (dto.UserUpdateResponse, error) {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
 	if err != nil {
-		return authDto.TokenResponse{}, dto.ErrEmailNotFound
+		return dto.UserUpdateResponse{}, dto.ErrUserNotFound
 	}
 
-	isValid, err := helpers.CheckPassword(user.Password, []byte(req.Password))
-	if err != nil || !isValid {
-		return authDto.TokenResponse{}, dto.ErrUserNotFound
+	// A new address has to be confirmed through the email change flow first
+	if req.Email != "" && req.Email != user.Email {
+		return dto.UserUpdateResponse{}, dto.ErrEmailChangeNeedsConfirm
 	}
 
-	accessToken := s.jwtService.GenerateAccessToken(user.ID.String(), user.Role)
-	refreshTokenString, expiresAt := s.jwtService.GenerateRefreshToken()
+	// Only the columns a user may change are written, so a role or
+	// verification set since the read above is not put back
+	columns := make(map[string]any)
+	if req.Name != "" {
+		columns["name"] = req.Name
+	}
+	if req.TelpNumber != "" {
+		columns["telp_number"] = req.TelpNumber
+	}
 
-	refreshToken := entities.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		Token:     refreshTokenString,
-		ExpiresAt: expiresAt,
+	if err := s.userRepository.UpdateColumns(ctx, s.db, userId, columns); err != nil {
+		return dto.UserUpdateResponse{}, dto.ErrUpdateUser
 	}
 
-	_, err = s.refreshTokenRepository.Create(ctx, s.db, refreshToken)
+	updatedUser, err := s.userRepository.GetUserById(ctx, s.db, userId)
 	if err != nil {
-		return authDto.TokenResponse{}, err
+		return dto.UserUpdateResponse{}, dto.ErrUserNotFound
 	}
 
-	return authDto.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshTokenString,
-		Role:         user.Role,
+	return dto.UserUpdateResponse{
+		ID:         updatedUser.ID.String(),
+		Name:       updatedUser.Name,
+		TelpNumber: updatedUser.TelpNumber,
+		Role:       updatedUser.Role,
+		Email:      updatedUser.Email,
+		IsVerified: updatedUser.IsVerified,
 	}, nil
 }
 
-func (s *userService) SendVerificationEmail(ctx context.Context, req dto.SendVerificationEmailRequest) error {
-	user, err := s.userRepository.GetUserByEmail(ctx, s.db, req.Email)
+// AdminUpdate also sets the admin-only fields, issued tokens are revoked
+// once a new role is written. A new email address still
+// has to be confirmed by its owner, so it only starts the email change flow.
+func (s *userService) AdminUpdate(
+	ctx context.Context,
+	req dto.UserAdminUpdateRequest,
+	userId string,
+) (dto.UserUpdateResponse, error) {
+	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
 	if err != nil {
-		return dto.ErrEmailNotFound
+		return dto.UserUpdateResponse{}, dto.ErrUserNotFound
 	}
 
-	if user.IsVerified {
-		return dto.ErrAccountAlreadyVerified
+	roleChanged := req.Role != "" && req.Role != user.Role
+	if roleChanged {
+		if err := s.rbacService.CheckRole(ctx, req.Role); err != nil {
+			return dto.UserUpdateResponse{}, err
+		}
 	}
 
-	verificationToken := s.jwtService.GenerateAccessToken(user.ID.String(), "verification")
-
-	subject := "Email Verification"
-	body := "Please verify your email using this token: " + verificationToken
-
-	return utils.SendMail(user.Email, subject, body)
-}
-
-func (s *userService) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (dto.VerifyEmailResponse, error) {
-	token, err := s.jwtService.ValidateToken(req.Token)
-	if err != nil || !token.Valid {
-		return dto.VerifyEmailResponse{}, dto.ErrTokenInvalid
+	if req.Email != "" && !strings.EqualFold(req.Email, user.Email) {
+		if err := s.emailChangeService.AdminRequestChange(ctx, userId, req.Email); err != nil {
+			return dto.UserUpdateResponse{}, err
+		}
 	}
 
-	userId, err := s.jwtService.GetUserIDByToken(req.Token)
-	if err != nil {
-		return dto.VerifyEmailResponse{}, dto.ErrTokenInvalid
+	columns := make(map[string]any)
+	if req.Name != "" {
+		columns["name"] = req.Name
 	}
-
-	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
-	if err != nil {
-		return dto.VerifyEmailResponse{}, dto.ErrUserNotFound
+	if req.TelpNumber != "" {
+		columns["telp_number"] = req.TelpNumber
 	}
-
-	user.IsVerified = true
-	updatedUser, err := s.userRepository.Update(ctx, s.db, user)
-	if err != nil {
-		return dto.VerifyEmailResponse{}, err
+	if req.IsVerified != nil {
+		columns["is_verified"] = *req.IsVerified
 	}
-
-	return dto.VerifyEmailResponse{
-		Email:      updatedUser.Email,
-		IsVerified: updatedUser.IsVerified,
-	}, nil
-}
-
-func (s *userService) Update(ctx context.Context, req dto.UserUpdateRequest, userId string) (dto.UserUpdateResponse, error) {
-	user, err := s.userRepository.GetUserById(ctx, s.db, userId)
-	if err != nil {
-		return dto.UserUpdateResponse{}, dto.ErrUserNotFound
+	if roleChanged {
+		columns["role"] = req.Role
 	}
 
-	if req.Name != "" {
-		user.Name = req.Name
+	// One statement for every column, the role included, so a failure leaves
+	// nothing half applied
+	if err := s.userRepository.UpdateColumns(ctx, s.db, userId, columns); err != nil {
+		return dto.UserUpdateResponse{}, dto.ErrUpdateUser
 	}
-	if req.Email != "" {
-		user.Email = req.Email
-	}
-	if req.TelpNumber != "" {
-		user.TelpNumber = req.TelpNumber
+
+	if roleChanged {
+		if err := s.revocationService.RevokeUserTokens(ctx, userId); err != nil {
+			return dto.UserUpdateResponse{}, err
+		}
 	}
 
-	updatedUser, err := s.userRepository.Update(ctx, s.db, user)
+	updatedUser, err := s.userRepository.GetUserById(ctx, s.db, userId)
 	if err != nil {
-		return dto.UserUpdateResponse{}, err
+		return dto.UserUpdateResponse{}, dto.ErrUserNotFound
 	}
 
 	return dto.UserUpdateResponse{
@@ -210,39 +194,100 @@ func (s *userService) Update(ctx context.Context, req dto.UserUpdateRequest, use
 	}, nil
 }
 
+// Delete soft deletes the user and signs them out. The account can be
+// restored until the grace period ends and it is erased.
 func (s *userService) Delete(ctx context.Context, userId string) error {
-	return s.userRepository.Delete(ctx, s.db, userId)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.userRepository.Delete(ctx, tx, userId); err != nil {
+			return err
+		}
+
+		// Sessions no longer go away with the row, so drop them here
+		return s.refreshTokenRepository.DeleteByUserID(ctx, tx, userId)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.revocationService.RevokeUserTokens(ctx, userId)
 }
 
-func (s *userService) RefreshToken(ctx context.Context, req authDto.RefreshTokenRequest) (authDto.TokenResponse, error) {
-	refreshToken, err := s.refreshTokenRepository.FindByToken(ctx, s.db, req.RefreshToken)
-	if err != nil {
-		return authDto.TokenResponse{}, err
+func (s *userService) Restore(ctx context.Context, userId string) (dto.UserResponse, error) {
+	if err := s.userRepository.Restore(ctx, s.db, userId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.UserResponse{}, dto.ErrUserNotDeleted
+		}
+		return dto.UserResponse{}, err
+	}
+
+	return s.GetUserById(ctx, userId)
+}
+
+// Erase removes the user and everything tied to them right away, for
+// erasure requests that can not wait for the grace period
+func (s *userService) Erase(ctx context.Context, userId string) error {
+	if err := s.erase(ctx, userId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return dto.ErrUserNotFound
+		}
+		return err
 	}
 
-	accessToken := s.jwtService.GenerateAccessToken(refreshToken.UserID.String(), refreshToken.User.Role)
-	newRefreshTokenString, expiresAt := s.jwtService.GenerateRefreshToken()
+	return s.revocationService.RevokeUserTokens(ctx, userId)
+}
 
-	err = s.refreshTokenRepository.DeleteByToken(ctx, s.db, req.RefreshToken)
+// erase removes the row and then the uploaded files and export archives
+// nothing refers to anymore
+func (s *userService) erase(ctx context.Context, userId string) error {
+	user, err := s.userRepository.Erase(ctx, s.db, userId)
 	if err != nil {
-		return authDto.TokenResponse{}, err
+		return err
 	}
 
-	newRefreshToken := entities.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    refreshToken.UserID,
-		Token:     newRefreshTokenString,
-		ExpiresAt: expiresAt,
+	if user.AvatarKey != "" {
+		if err := s.avatarService.Delete(ctx, user.AvatarKey); err != nil {
+			log.Println(err)
+		}
 	}
 
-	_, err = s.refreshTokenRepository.Create(ctx, s.db, newRefreshToken)
-	if err != nil {
-		return authDto.TokenResponse{}, err
+	if err := s.dataExportService.DeleteUserExports(ctx, userId); err != nil {
+		log.Println(err)
 	}
 
-	return authDto.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshTokenString,
-		Role:         refreshToken.User.Role,
-	}, nil
-}
\ No newline at end of file
+	return nil
+}
+
+// eraseDeletedUsers erases, on every tick, the users whose grace period is
+// over. Each user is erased on its own so one failure does not hold up the
+// rest.
+func (s *userService) eraseDeletedUsers(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		cutoff := time.Now().Add(-s.deletionConfig.GracePeriod)
+
+		for {
+			users, err := s.userRepository.FindDeletedBefore(ctx, s.db, cutoff, erasureBatchSize)
+			if err != nil {
+				log.Println(err)
+				break
+			}
+
+			erased := 0
+			for _, user := range users {
+				if err := s.erase(ctx, user.ID.String()); err != nil {
+					log.Println(err)
+					continue
+				}
+				erased++
+			}
+
+			// A full batch may have more behind it, unless nothing could be erased
+			if len(users) < erasureBatchSize || erased == 0 {
+				break
+			}
+		}
+	}
+}
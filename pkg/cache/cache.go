@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotInteger = errors.New("cache: value is not an integer")
+
+// Store is a key/value store with per-key expiry. Its semantics map directly
+// onto Redis GET / SET EX / DEL / INCR, so a Redis client can be plugged in
+// for deployments running more than one instance.
+type Store interface {
+	Get(ctx context.Context, key string) (string, bool, error)
+	Set(ctx context.Context, key string, value string, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// Incr atomically adds one to the counter at key and returns the new
+	// count. A missing key starts at zero and expires after ttl; later
+	// increments keep that expiry (INCR, then EXPIRE when the count is 1).
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
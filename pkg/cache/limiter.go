@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// Limiter allows a number of requests per key in fixed windows. Requests are
+// counted with Store.Incr, so parallel requests can not overshoot the limit.
+type Limiter struct {
+	store Store
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Allow counts a request and reports how long to wait once more than limit
+// requests were made within the window. A zero duration allows the request.
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (time.Duration, error) {
+	count, err := l.store.Incr(ctx, key, window)
+	if err != nil {
+		return 0, err
+	}
+
+	// The request opening the window records when it ends, for Retry-After
+	untilKey := key + ":until"
+	if count == 1 {
+		until := strconv.FormatInt(time.Now().Add(window).UnixMilli(), 10)
+		return 0, l.store.Set(ctx, untilKey, until, window)
+	}
+	if count <= int64(limit) {
+		return 0, nil
+	}
+
+	value, _, err := l.store.Get(ctx, untilKey)
+	if err != nil {
+		return 0, err
+	}
+	until, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		// Not written yet by the request opening the window
+		return window, nil
+	}
+
+	return max(time.Until(time.UnixMilli(until)), time.Second), nil
+}
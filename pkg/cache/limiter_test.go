@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewLimiter(NewMemoryStore())
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			retryAfter, err := limiter.Allow(ctx, "key", 5, time.Hour)
+			if err != nil {
+				t.Error(err)
+			}
+			if retryAfter == 0 {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed.Load() != 5 {
+		t.Errorf("%d of 50 concurrent requests allowed, want 5", allowed.Load())
+	}
+
+	retryAfter, err := limiter.Allow(ctx, "key", 5, time.Hour)
+	if err != nil || retryAfter <= time.Minute*59 || retryAfter > time.Hour {
+		t.Errorf("request past the limit: %v, %v", retryAfter, err)
+	}
+
+	// A new window starts once the old one ends
+	if retryAfter, err := limiter.Allow(ctx, "short", 1, time.Millisecond*10); err != nil || retryAfter != 0 {
+		t.Fatalf("first request: %v, %v", retryAfter, err)
+	}
+	if retryAfter, _ := limiter.Allow(ctx, "short", 1, time.Millisecond*10); retryAfter == 0 {
+		t.Fatal("second request in the window allowed")
+	}
+	time.Sleep(time.Millisecond * 20)
+	if retryAfter, err := limiter.Allow(ctx, "short", 1, time.Millisecond*10); err != nil || retryAfter != 0 {
+		t.Errorf("request in a new window: %v, %v", retryAfter, err)
+	}
+}
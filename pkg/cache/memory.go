@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	lastSweep time.Time
+}
+
+// NewMemoryStore returns a process-local Store. Expired keys are dropped lazily.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		items:     make(map[string]memoryItem),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *memoryStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		delete(s.items, key)
+		return "", false, nil
+	}
+
+	return item.value, true, nil
+}
+
+func (s *memoryStore) Set(_ context.Context, key string, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := memoryItem{value: value}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	s.items[key] = item
+
+	s.sweep()
+	return nil
+}
+
+func (s *memoryStore) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if ok && !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		ok = false
+	}
+
+	var count int64
+	if ok {
+		var err error
+		count, err = strconv.ParseInt(item.value, 10, 64)
+		if err != nil {
+			return 0, ErrNotInteger
+		}
+	} else {
+		item = memoryItem{}
+		if ttl > 0 {
+			item.expiresAt = time.Now().Add(ttl)
+		}
+	}
+
+	count++
+	item.value = strconv.FormatInt(count, 10)
+	s.items[key] = item
+
+	s.sweep()
+	return count, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, key)
+	return nil
+}
+
+// sweep removes expired keys at most once per sweepInterval. Callers hold the lock.
+func (s *memoryStore) sweep() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, item := range s.items {
+		if !item.expiresAt.IsZero() && now.After(item.expiresAt) {
+			delete(s.items, key)
+		}
+	}
+	s.lastSweep = now
+}
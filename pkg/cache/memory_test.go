@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreIncr(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Incr(ctx, "counter", time.Minute); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _, _ := s.Get(ctx, "counter"); value != "100" {
+		t.Errorf("counter = %q after 100 concurrent increments", value)
+	}
+
+	// The expiry is set by the first increment and not extended
+	if _, err := s.Incr(ctx, "short", time.Millisecond*20); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 15)
+	if count, _ := s.Incr(ctx, "short", time.Millisecond*20); count != 2 {
+		t.Fatalf("second increment = %d", count)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if count, _ := s.Incr(ctx, "short", time.Millisecond*20); count != 1 {
+		t.Errorf("increment after expiry = %d, want a new counter", count)
+	}
+
+	if err := s.Set(ctx, "text", "abc", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Incr(ctx, "text", 0); !errors.Is(err, ErrNotInteger) {
+		t.Errorf("Incr of a string: %v", err)
+	}
+}
@@ -1,8 +1,17 @@
 package constants
 
 const (
-	ENUM_ROLE_ADMIN = "admin"
-	ENUM_ROLE_USER  = "user"
+	ENUM_ROLE_ADMIN  = "admin"
+	ENUM_ROLE_AUTHOR = "author"
+	ENUM_ROLE_USER   = "user"
+
+	ENUM_PERMISSION_USER_LIST           = "user:list"
+	ENUM_PERMISSION_USER_READ           = "user:read"
+	ENUM_PERMISSION_USER_UPDATE         = "user:update"
+	ENUM_PERMISSION_USER_DELETE         = "user:delete"
+	ENUM_PERMISSION_ROLE_READ           = "role:read"
+	ENUM_PERMISSION_ROLE_MANAGE         = "role:manage"
+	ENUM_PERMISSION_OAUTH_CLIENT_MANAGE = "oauth_client:manage"
 
 	ENUM_RUN_PRODUCTION = "production"
 	ENUM_RUN_TESTING    = "testing"
@@ -10,6 +19,32 @@ const (
 	ENUM_PAGINATION_PER_PAGE = 10
 	ENUM_PAGINATION_PAGE     = 1
 
-	DB         = "db"
-	JWTService = "JWTService"
-)
\ No newline at end of file
+	ENUM_TOKEN_PURPOSE_ACCESS             = "access"
+	ENUM_TOKEN_PURPOSE_EMAIL_VERIFICATION = "email_verification"
+	ENUM_TOKEN_PURPOSE_PASSWORD_RESET     = "password_reset"
+	ENUM_TOKEN_PURPOSE_TWO_FACTOR         = "two_factor_challenge"
+	ENUM_TOKEN_PURPOSE_MAGIC_LINK         = "magic_link"
+	ENUM_TOKEN_PURPOSE_OAUTH_ACCESS       = "oauth_access"
+	ENUM_TOKEN_PURPOSE_API_KEY            = "api_key"
+
+	ENUM_OAUTH_GRANT_AUTHORIZATION_CODE = "authorization_code"
+	ENUM_OAUTH_GRANT_CLIENT_CREDENTIALS = "client_credentials"
+
+	DB                     = "db"
+	JWTService             = "JWTService"
+	CacheStore             = "CacheStore"
+	TokenRevocationService = "TokenRevocationService"
+	RBACService            = "RBACService"
+	LoginThrottleService   = "LoginThrottleService"
+	OAuthProviders         = "OAuthProviders"
+	MagicLinkConfig        = "MagicLinkConfig"
+	TwoFactorConfig        = "TwoFactorConfig"
+	EmailChangeConfig      = "EmailChangeConfig"
+	UserDeletionConfig     = "UserDeletionConfig"
+	WebAuthn               = "WebAuthn"
+	APIKeyService          = "APIKeyService"
+	PasswordPolicyService  = "PasswordPolicyService"
+	DataExportConfig       = "DataExportConfig"
+	AvatarConfig           = "AvatarConfig"
+	Storage                = "Storage"
+)
@@ -0,0 +1,243 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/do"
+)
+
+// Record is one row of a section, keyed by field name
+type Record map[string]any
+
+// Exporter contributes one section to the personal data export of a user
+type Exporter interface {
+	// Name names the section and its files in the archive
+	Name() string
+	// Count is how many records Export returns, read without loading them so
+	// an export can be sized up before it is built
+	Count(ctx context.Context, userId string) (int64, error)
+	Export(ctx context.Context, userId string) ([]Record, error)
+}
+
+// Section is the exported data of one exporter
+type Section struct {
+	Name    string
+	Records []Record
+}
+
+const exporterPrefix = "DataExporter:"
+
+// Provide registers an exporter with the injector. Every registered exporter
+// is part of each export, so a module only has to provide its own.
+func Provide(injector *do.Injector, exporter Exporter) {
+	do.ProvideNamedValue(injector, exporterPrefix+exporter.Name(), exporter)
+}
+
+// Exporters returns the registered exporters sorted by name
+func Exporters(injector *do.Injector) ([]Exporter, error) {
+	var names []string
+	for _, name := range injector.ListProvidedServices() {
+		if strings.HasPrefix(name, exporterPrefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	exporters := make([]Exporter, 0, len(names))
+	for _, name := range names {
+		exporter, err := do.InvokeNamed[Exporter](injector, name)
+		if err != nil {
+			return nil, err
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	return exporters, nil
+}
+
+type exporterFunc struct {
+	name   string
+	count  func(ctx context.Context, userId string) (int64, error)
+	export func(ctx context.Context, userId string) ([]Record, error)
+}
+
+// NewExporter builds an exporter from a counting and an exporting function
+func NewExporter(
+	name string,
+	count func(ctx context.Context, userId string) (int64, error),
+	export func(ctx context.Context, userId string) ([]Record, error),
+) Exporter {
+	return &exporterFunc{
+		name:   name,
+		count:  count,
+		export: export,
+	}
+}
+
+func (e *exporterFunc) Name() string {
+	return e.name
+}
+
+func (e *exporterFunc) Count(ctx context.Context, userId string) (int64, error) {
+	return e.count(ctx, userId)
+}
+
+func (e *exporterFunc) Export(ctx context.Context, userId string) ([]Record, error) {
+	return e.export(ctx, userId)
+}
+
+// ToRecords converts an entity, or a slice of them, to records through their
+// JSON form, so fields hidden from JSON such as hashes stay out of the export
+func ToRecords(value any) ([]Record, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		var records []Record
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, err
+		}
+		return records, nil
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	return []Record{record}, nil
+}
+
+// Count adds up the records every exporter would export for the user
+func Count(ctx context.Context, exporters []Exporter, userId string) (int64, error) {
+	var total int64
+	for _, exporter := range exporters {
+		count, err := exporter.Count(ctx, userId)
+		if err != nil {
+			return 0, fmt.Errorf("count %s: %w", exporter.Name(), err)
+		}
+		total += count
+	}
+
+	return total, nil
+}
+
+// Collect runs every exporter for the user
+func Collect(ctx context.Context, exporters []Exporter, userId string) ([]Section, error) {
+	sections := make([]Section, 0, len(exporters))
+	for _, exporter := range exporters {
+		records, err := exporter.Export(ctx, userId)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", exporter.Name(), err)
+		}
+		sections = append(sections, Section{Name: exporter.Name(), Records: records})
+	}
+
+	return sections, nil
+}
+
+// Archive zips every section as <name>.json and <name>.csv
+func Archive(sections []Section) ([]byte, error) {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	for _, section := range sections {
+		records := section.Records
+		if records == nil {
+			records = []Record{}
+		}
+
+		jsonFile, err := archive.Create(section.Name + ".json")
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(jsonFile)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			return nil, err
+		}
+
+		csvFile, err := archive.Create(section.Name + ".csv")
+		if err != nil {
+			return nil, err
+		}
+		if err := writeCSV(csvFile, records); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// writeCSV uses the union of all record fields as columns, sorted by name
+func writeCSV(file io.Writer, records []Record) error {
+	columnSet := make(map[string]bool)
+	for _, record := range records {
+		for column := range record {
+			columnSet[column] = true
+		}
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = formatCSVValue(record[column])
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatCSVValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		// Spreadsheets run cells starting with these as formulas
+		if v != "" && strings.ContainsRune("=+-@", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		// Nested values keep their JSON form
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
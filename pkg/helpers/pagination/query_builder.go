@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"gorm.io/gorm"
@@ -84,11 +85,39 @@ const (
 
 // PaginatedQueryOptions provides configuration for paginated queries
 type PaginatedQueryOptions struct {
-	Dialect          DatabaseDialect
+	Dialect DatabaseDialect
+	// EnableSoftDelete skips rows with a deleted_at, it is switched on for
+	// models with a gorm.DeletedAt field
 	EnableSoftDelete bool
 	CustomCountQuery string
 }
 
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+// hasSoftDelete reports whether T, or a struct embedded in it, has a
+// gorm.DeletedAt field
+func hasSoftDelete[T any]() bool {
+	return hasDeletedAtField(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+func hasDeletedAtField(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type == deletedAtType {
+			return true
+		}
+		if field.Anonymous && hasDeletedAtField(field.Type) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func PaginatedQuery[T any](
 	db *gorm.DB,
 	builder QueryBuilder,
@@ -152,6 +181,13 @@ func PaginatedQueryWithOptions[T any](
 	var result []T
 	var totalCount int64
 
+	// gorm filters the data query of such models on its own, the count query
+	// has no model and needs the condition added
+	modelSoftDelete := hasSoftDelete[T]()
+	if modelSoftDelete {
+		options.EnableSoftDelete = true
+	}
+
 	// Build count query
 	countQuery := db.Table(builder.GetTableName())
 	countQuery = builder.ApplyFilters(countQuery)
@@ -181,7 +217,7 @@ func PaginatedQueryWithOptions[T any](
 	}
 
 	// Apply soft delete handling if enabled
-	if options.EnableSoftDelete {
+	if options.EnableSoftDelete && !modelSoftDelete {
 		dataQuery = dataQuery.Where("deleted_at IS NULL")
 	}
 
@@ -1,22 +1,209 @@
 package helpers
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-/** HashPassword hashes a plain password using bcrypt */
+var (
+	ErrPasswordMismatch        = errors.New("password does not match")
+	ErrUnsupportedPasswordHash = errors.New("unsupported password hash format")
+)
+
+/** PasswordHasher hashes passwords into a self-describing encoded string */
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password
+	Hash(password string) (string, error)
+	// Identifies reports whether encoded was produced by this algorithm
+	Identifies(encoded string) bool
+	// Verify checks password against encoded. upgrade is true when encoded
+	// uses weaker parameters than the hasher is configured with.
+	Verify(encoded string, password []byte) (match bool, upgrade bool, err error)
+}
+
+var (
+	passwordHasher PasswordHasher = DefaultArgon2idHasher()
+	// passwordHashers can verify every format a stored password may be in
+	passwordHashers = []PasswordHasher{
+		DefaultArgon2idHasher(),
+		DefaultBcryptHasher(),
+	}
+)
+
+/** SetPasswordHasher changes the hasher used by HashPassword, hashes of any other kind are upgraded on login */
+func SetPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+}
+
+/** HashPassword hashes a plain password with the configured hasher */
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 4)
+	return passwordHasher.Hash(password)
+}
+
+/** CheckPassword compares a hashed password in any supported format with a plain password. needsUpgrade is true when the hash should be replaced by a HashPassword result */
+func CheckPassword(hashPassword string, plainPassword []byte) (valid bool, needsUpgrade bool, err error) {
+	hasher := passwordHasher
+	if !hasher.Identifies(hashPassword) {
+		hasher = nil
+		for _, h := range passwordHashers {
+			if h.Identifies(hashPassword) {
+				hasher = h
+				break
+			}
+		}
+		if hasher == nil {
+			return false, false, ErrUnsupportedPasswordHash
+		}
+		// Another algorithm than the configured one is always upgraded
+		needsUpgrade = true
+	}
+
+	valid, upgrade, err := hasher.Verify(hashPassword, plainPassword)
+	if err != nil || !valid {
+		return false, false, err
+	}
+
+	return true, needsUpgrade || upgrade, nil
+}
+
+/** BcryptHasher hashes passwords with bcrypt, encoded in the $2a$ modular crypt format */
+type BcryptHasher struct {
+	Cost int
+}
+
+func DefaultBcryptHasher() *BcryptHasher {
+	return &BcryptHasher{Cost: bcrypt.DefaultCost}
+}
+
+/** NewBcryptHasher returns a bcrypt hasher after checking the cost is in range */
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return &BcryptHasher{Cost: cost}, nil
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	return string(bytes), err
 }
 
-/** CheckPassword compares a hashed password with a plain password */
-func CheckPassword(hashPassword string, plainPassword []byte) (bool, error) {
-	hashPW := []byte(hashPassword)
-	err := bcrypt.CompareHashAndPassword(hashPW, plainPassword)
+func (h *BcryptHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h *BcryptHasher) Verify(encoded string, password []byte) (bool, bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), password)
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, ErrPasswordMismatch
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return false, false, err
+	}
+
+	return true, cost < h.Cost, nil
+}
+
+/** Argon2idHasher hashes passwords with argon2id, encoded in the PHC string format */
+type Argon2idHasher struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+/** DefaultArgon2idHasher uses the OWASP minimum of 19 MiB, 2 iterations and 1 thread */
+func DefaultArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+const argon2idPrefix = "$argon2id$"
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.Memory,
+		h.Iterations,
+		h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Identifies(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func (h *Argon2idHasher) Verify(encoded string, password []byte) (bool, bool, error) {
+	// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnsupportedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnsupportedPasswordHash
+	}
+
+	var stored Argon2idHasher
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &stored.Memory, &stored.Iterations, &stored.Parallelism); err != nil ||
+		stored.Iterations == 0 || stored.Parallelism == 0 {
+		return false, false, ErrUnsupportedPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return false, err
+		return false, false, ErrUnsupportedPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, false, ErrUnsupportedPasswordHash
 	}
 
-	return true, nil
-}
\ No newline at end of file
+	computed := argon2.IDKey(password, salt, stored.Iterations, stored.Memory, stored.Parallelism, uint32(len(key)))
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false, ErrPasswordMismatch
+	}
+
+	upgrade := stored.Memory < h.Memory ||
+		stored.Iterations < h.Iterations ||
+		stored.Parallelism < h.Parallelism ||
+		uint32(len(salt)) < h.SaltLength ||
+		uint32(len(key)) < h.KeyLength
+
+	return true, upgrade, nil
+}
@@ -0,0 +1,13 @@
+package helpers
+
+/** TruncateRunes cuts s to at most n characters, never inside a multi-byte one, as varchar(n) counts characters */
+func TruncateRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
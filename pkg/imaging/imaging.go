@@ -0,0 +1,119 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooLarge        = errors.New("image dimensions too large")
+	ErrTooSmall        = errors.New("image dimensions too small")
+)
+
+// contentTypes are the sniffed types accepted, keyed to the format name the
+// image package registers their decoder under
+var contentTypes = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+type Limits struct {
+	MinDimension int
+	MaxDimension int
+}
+
+// Image is a decoded upload. Only pixels are kept, so metadata such as EXIF
+// never reaches an encoded copy.
+type Image struct {
+	img         image.Image
+	format      string
+	orientation int
+}
+
+// Decode sniffs the content type of data, whatever name or type the client
+// claimed, and checks the dimensions from the header before decoding any
+// pixels
+func Decode(data []byte, limits Limits) (*Image, error) {
+	format, ok := contentTypes[http.DetectContentType(data)]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, configFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || configFormat != format {
+		return nil, ErrUnsupportedType
+	}
+	if cfg.Width > limits.MaxDimension || cfg.Height > limits.MaxDimension {
+		return nil, ErrTooLarge
+	}
+	if cfg.Width < limits.MinDimension || cfg.Height < limits.MinDimension {
+		return nil, ErrTooSmall
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+
+	return &Image{img: img, format: format, orientation: orientation}, nil
+}
+
+// Extension is the file extension of what Encode writes
+func (i *Image) Extension() string {
+	if i.format == "jpeg" {
+		return "jpg"
+	}
+	return "png"
+}
+
+// ContentType is the content type of what Encode writes
+func (i *Image) ContentType() string {
+	if i.format == "jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// Square crops the largest centered square and scales it to size x size,
+// turned upright as the EXIF orientation says
+func (i *Image) Square(size int) image.Image {
+	bounds := i.img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Point{
+		X: bounds.Min.X + (bounds.Dx()-side)/2,
+		Y: bounds.Min.Y + (bounds.Dy()-side)/2,
+	})
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), i.img, crop, draw.Src, nil)
+
+	// A centered square maps onto itself under every orientation, so turning
+	// the small result is the same as turning the source and a lot cheaper
+	return orient(dst, i.orientation)
+}
+
+// Encode writes img as a JPEG for JPEG uploads and as a PNG otherwise, which
+// keeps transparency
+func (i *Image) Encode(w io.Writer, img image.Image) error {
+	if i.format == "jpeg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStorage(t *testing.T) *localStorage {
+	t.Helper()
+
+	s, err := NewLocalStorage(t.TempDir(), "http://localhost:8888/files/", []byte("signing key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*localStorage)
+}
+
+// serve requests a signed URL from the storage's own handler
+func serve(t *testing.T, s *localStorage, signedURL string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	u, err := url.Parse(signedURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	return rec
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	key := "avatars/user/abc.png"
+
+	if err := s.Put(ctx, key, strings.NewReader("content"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	info, err := s.Stat(ctx, key)
+	if err != nil || info.Size != 7 || info.ContentType != "image/png" {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+
+	body, _, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	body.Close()
+	if string(content) != "content" {
+		t.Errorf("Get = %q", content)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete: %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing object: %v", err)
+	}
+
+	if err := s.Put(ctx, "../outside", strings.NewReader("x"), ""); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put outside of the root: %v", err)
+	}
+}
+
+func TestLocalStorageList(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+
+	if objects, err := s.List(ctx, "exports/"); err != nil || len(objects) != 0 {
+		t.Fatalf("List of a missing directory = %+v, %v", objects, err)
+	}
+
+	for _, key := range []string{"exports/a/one.zip", "exports/a/two.zip", "exports/b/one.zip", "avatars/one.png"} {
+		if err := s.Put(ctx, key, strings.NewReader("content"), ""); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+	}
+
+	for prefix, want := range map[string]int{"exports/": 3, "exports/a/": 2, "exports/a/t": 1, "exports/c/": 0} {
+		objects, err := s.List(ctx, prefix)
+		if err != nil {
+			t.Fatalf("List %s: %v", prefix, err)
+		}
+		if len(objects) != want {
+			t.Errorf("List %s = %+v, want %d objects", prefix, objects, want)
+		}
+		for _, object := range objects {
+			if !strings.HasPrefix(object.Key, prefix) || object.Size != 7 {
+				t.Errorf("List %s returned %+v", prefix, object)
+			}
+		}
+	}
+
+	if _, err := s.List(ctx, "../"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("List outside of the root: %v", err)
+	}
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	for _, key := range []string{"avatars/a.png", "avatars/b.png"} {
+		if err := s.Put(ctx, key, strings.NewReader(key), "image/png"); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+	}
+
+	signedURL, err := s.SignedURL(ctx, "avatars/a.png", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	if !strings.HasPrefix(signedURL, "http://localhost:8888/files/avatars/a.png?") {
+		t.Fatalf("SignedURL = %q", signedURL)
+	}
+
+	rec := serve(t, s, signedURL)
+	if rec.Code != http.StatusOK || rec.Body.String() != "avatars/a.png" {
+		t.Fatalf("signed URL answered %d %q", rec.Code, rec.Body.String())
+	}
+	for header, want := range map[string]string{
+		"Content-Type":            "image/png",
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "sandbox",
+	} {
+		if got := rec.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	u, _ := url.Parse(signedURL)
+	query := u.Query()
+
+	tampered := map[string]func(u *url.URL, q url.Values){
+		"signature":     func(_ *url.URL, q url.Values) { q.Set("signature", q.Get("signature")[1:]+"A") },
+		"no signature":  func(_ *url.URL, q url.Values) { q.Del("signature") },
+		"later expiry":  func(_ *url.URL, q url.Values) { q.Set("expires", q.Get("expires")+"0") },
+		"no expiry":     func(_ *url.URL, q url.Values) { q.Del("expires") },
+		"other key":     func(u *url.URL, _ url.Values) { u.Path = "/files/avatars/b.png" },
+		"outside mount": func(u *url.URL, _ url.Values) { u.Path = "/other/avatars/a.png" },
+	}
+	for name, tamper := range tampered {
+		changed := *u
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = append([]string(nil), v...)
+		}
+		tamper(&changed, q)
+		changed.RawQuery = q.Encode()
+
+		if rec := serve(t, s, changed.String()); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: answered %d, want 404", name, rec.Code)
+		}
+	}
+}
+
+func TestLocalStorageSignedURLExpiry(t *testing.T) {
+	ctx := context.Background()
+	s := newTestLocalStorage(t)
+	if err := s.Put(ctx, "avatars/a.png", strings.NewReader("a"), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	expired, err := s.SignedURL(ctx, "avatars/a.png", -time.Second)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	if rec := serve(t, s, expired); rec.Code != http.StatusNotFound {
+		t.Errorf("expired URL answered %d", rec.Code)
+	}
+
+	// A correctly signed URL for a traversing key is still refused
+	expires := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign("../secret", expires)}}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/../secret?"+query.Encode(), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("traversing key answered %d", rec.Code)
+	}
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"blog/config"
+)
+
+var ErrPresignExpiry = errors.New("storage: s3 signed URLs can live for 7 days at most")
+
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+}
+
+type s3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Storage talks to an S3 compatible service over its REST API, signing
+// every request with AWS Signature Version 4
+func NewS3Storage(cfg S3Config) (Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("storage: s3 bucket is required")
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("storage: invalid s3 endpoint %q", cfg.Endpoint)
+	}
+
+	return &s3Storage{
+		config:   cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	if _, err := CleanKey(key); err != nil {
+		return err
+	}
+
+	// S3 wants the length up front. A seekable reader is measured and streamed
+	// with an unsigned payload, anything else is read into memory and its hash
+	// signed.
+	var (
+		body        io.Reader
+		size        int64
+		payloadHash string
+	)
+	if seeker, ok := r.(io.ReadSeeker); ok {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+
+		// Limited so the client neither closes the caller's reader nor sends
+		// more than announced
+		body, size, payloadHash = io.LimitReader(seeker, end-start), end-start, unsignedPayload
+	} else {
+		payload, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		body, size, payloadHash = bytes.NewReader(payload), int64(len(payload)), hashHex(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := s.do(req, payloadHash)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	res, err := s.object(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	return res.Body, s3ObjectInfo(key, res), nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	res, err := s.object(ctx, http.MethodDelete, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	res.Body.Close()
+
+	return nil
+}
+
+func (s *s3Storage) SignedURL(_ context.Context, key string, expiry time.Duration) (string, error) {
+	if _, err := CleanKey(key); err != nil {
+		return "", err
+	}
+	if expiry > config.S3MaxSignedURLExpiry {
+		return "", ErrPresignExpiry
+	}
+
+	return s.presign(http.MethodGet, s.objectURL(key), expiry, time.Now()), nil
+}
+
+func (s *s3Storage) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	res, err := s.object(ctx, http.MethodHead, key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	res.Body.Close()
+
+	return s3ObjectInfo(key, res), nil
+}
+
+// List pages through ListObjectsV2, which returns up to 1000 keys at a time
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	prefix, err := cleanPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		objects           []ObjectInfo
+		continuationToken string
+	)
+	for {
+		u := s.objectURL("")
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		u.RawQuery = canonicalQuery(query)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := s.do(req, hashHex(nil))
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key          string
+				Size         int64
+				LastModified time.Time
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          object.Key,
+				Size:         object.Size,
+				LastModified: object.LastModified,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// object sends a request without a body for key
+func (s *s3Storage) object(ctx context.Context, method string, key string) (*http.Response, error) {
+	if _, err := CleanKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.do(req, hashHex(nil))
+}
+
+// do signs and sends the request. Any status but 2xx is turned into an
+// error, ErrNotFound for 404.
+func (s *s3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signRequest(req, payloadHash, time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("storage: s3 %s %s: %s", req.Method, req.URL.Path, res.Status)
+}
+
+// objectURL addresses key in the bucket, through the path for path style and
+// through the host name otherwise. Clean keys need no escaping.
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.config.PathStyle {
+		u.Path += "/" + s.config.Bucket + "/" + key
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path += "/" + key
+	}
+	return &u
+}
+
+func s3ObjectInfo(key string, res *http.Response) ObjectInfo {
+	size, _ := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64)
+	lastModified, _ := http.ParseTime(res.Header.Get("Last-Modified"))
+
+	return ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  res.Header.Get("Content-Type"),
+		LastModified: lastModified,
+	}
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"blog/config"
+	"blog/pkg/constants"
+)
+
+var (
+	ErrNotFound      = errors.New("storage: object not found")
+	ErrInvalidKey    = errors.New("storage: invalid key")
+	ErrNoSigningKey  = errors.New("storage: STORAGE_SIGNING_KEY is required in production")
+	ErrUnknownDriver = errors.New("storage: unknown driver")
+)
+
+// maxKeyLength is the longest key S3 accepts
+const maxKeyLength = 1024
+
+// Storage keeps uploaded files under slash separated keys such as
+// "avatars/<user id>/<sha256>.png". Keys are checked with CleanKey by every
+// implementation, so a key can never point outside of the storage.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get returns the content of the object, the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	// Delete removes the object, a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL is where clients can download the object from until expiry
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// List returns the objects whose key starts with prefix, such as
+	// "exports/" for every object below exports
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// Server is implemented by storages whose signed URLs point back at the app,
+// which then has to serve them under MountPath
+type Server interface {
+	http.Handler
+	MountPath() string
+}
+
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// New returns the storage driver selected in the configuration
+func New(cfg *config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case config.StorageDriverLocal:
+		signingKey := []byte(cfg.SigningKey)
+		if len(signingKey) == 0 {
+			if os.Getenv("APP_ENV") == constants.ENUM_RUN_PRODUCTION {
+				return nil, ErrNoSigningKey
+			}
+			// URLs signed with a random key stop working on restart, fine
+			// for development
+			signingKey = make([]byte, 32)
+			if _, err := rand.Read(signingKey); err != nil {
+				return nil, err
+			}
+		}
+		return NewLocalStorage(cfg.LocalRoot, cfg.LocalURL, signingKey)
+	case config.StorageDriverS3:
+		return NewS3Storage(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, cfg.Driver)
+	}
+}
+
+// CleanKey validates a key before it is turned into a path or URL. Segments
+// may only hold letters, digits, '.', '-' and '_', and "." or ".." are
+// rejected, which rules out traversal and any need for escaping.
+func CleanKey(key string) (string, error) {
+	if key == "" || len(key) > maxKeyLength {
+		return "", ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", ErrInvalidKey
+		}
+		for _, c := range segment {
+			if !isKeyChar(c) {
+				return "", ErrInvalidKey
+			}
+		}
+	}
+
+	return key, nil
+}
+
+// cleanPrefix validates a List prefix, which is a key or the start of one
+func cleanPrefix(prefix string) (string, error) {
+	if _, err := CleanKey(strings.TrimSuffix(prefix, "/")); err != nil {
+		return "", err
+	}
+	return prefix, nil
+}
+
+func isKeyChar(c rune) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '.' || c == '-' || c == '_'
+}
+
+// ContentKey names an object after the digest of its content, so the same
+// content always gets the same key and a key never changes content
+func ContentKey(prefix string, digest []byte, ext string) string {
+	key := hex.EncodeToString(digest)
+	if prefix != "" {
+		key = strings.TrimSuffix(prefix, "/") + "/" + key
+	}
+	if ext != "" {
+		key += "." + ext
+	}
+	return key
+}
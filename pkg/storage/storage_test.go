@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	valid := []string{
+		"avatars/user/abc.png",
+		"a",
+		"exports/0b6f1c2e-1f4a-4d0e-9c8e-3a1f0d9b7c6a/export-20260101000000-x_y.zip",
+		"..a/b..",
+	}
+	for _, key := range valid {
+		if got, err := CleanKey(key); err != nil || got != key {
+			t.Errorf("CleanKey(%q) = %q, %v", key, got, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"..",
+		"../secret",
+		"avatars/../../etc/passwd",
+		"avatars/./a.png",
+		"/avatars/a.png",
+		"avatars/a.png/",
+		"avatars//a.png",
+		`avatars\..\a.png`,
+		"avatars/%2e%2e/a.png",
+		"avatars/a b.png",
+		"avatars/a.png?x=1",
+		"avatars/a\x00.png",
+		"avatars/é.png",
+		strings.Repeat("a", maxKeyLength+1),
+	}
+	for _, key := range invalid {
+		if _, err := CleanKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CleanKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestContentKey(t *testing.T) {
+	digest := []byte{0xde, 0xad, 0xbe, 0xef}
+
+	if got := ContentKey("avatars/", digest, "png"); got != "avatars/deadbeef.png" {
+		t.Errorf("ContentKey = %q", got)
+	}
+	if got := ContentKey("", digest, ""); got != "deadbeef" {
+		t.Errorf("ContentKey without prefix and extension = %q", got)
+	}
+}
@@ -6,34 +6,17 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
-	"fmt"
 	"io"
 )
 
-const (
-	// todo
-	KEY = "8e71bbce7451ba2835de5aea73e4f3f96821455240823d2fd8174975b8321bfc!"
-)
+var ErrDecrypt = errors.New("error in decrypting")
 
 // https://www.melvinvivas.com/how-to-encrypt-and-decrypt-data-using-aes
 
-func AESEncrypt(stringToEncrypt string) (encryptedString string, err error) {
-	//Since the key is in string, we need to convert decode it to bytes
-	key, err := hex.DecodeString(KEY)
-	if err != nil {
-		return "", err
-	}
-	plaintext := []byte(stringToEncrypt)
-
-	//Create a new Cipher Block from the key
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	//Create a new GCM - https://en.wikipedia.org/wiki/Galois/Counter_Mode
-	//https://golang.org/pkg/crypto/cipher/#NewGCM
-	aesGCM, err := cipher.NewGCM(block)
+// AESEncrypt seals the string with AES-GCM under key, which must be 16, 24 or
+// 32 bytes, and returns the nonce and ciphertext hex encoded
+func AESEncrypt(key []byte, stringToEncrypt string) (string, error) {
+	aesGCM, err := newGCM(key)
 	if err != nil {
 		return "", err
 	}
@@ -44,23 +27,17 @@ func AESEncrypt(stringToEncrypt string) (encryptedString string, err error) {
 		return "", err
 	}
 
-	//Encrypt the data using aesGCM.Seal
 	//Since we don't want to save the nonce somewhere else in this case, we add it as a prefix to the encrypted data. The first nonce argument in Seal is the prefix.
-	ciphertext := aesGCM.Seal(nonce, nonce, plaintext, nil)
-	return fmt.Sprintf("%x", ciphertext), nil
+	ciphertext := aesGCM.Seal(nonce, nonce, []byte(stringToEncrypt), nil)
+	return hex.EncodeToString(ciphertext), nil
 }
 
-func AESDecrypt(encryptedString string) (decryptedString string, err error) {
-	defer func() {
-		if r := recover(); r != nil {
-			decryptedString = ""
-			err = errors.New("error in decrypting")
-		}
-	}()
-
-	key, err := hex.DecodeString(KEY)
+// AESDecrypt opens what AESEncrypt returned, failing on a wrong key or any
+// tampering
+func AESDecrypt(key []byte, encryptedString string) (string, error) {
+	aesGCM, err := newGCM(key)
 	if err != nil {
-		return "", errors.New("error in decoding key")
+		return "", err
 	}
 
 	enc, err := hex.DecodeString(encryptedString)
@@ -68,29 +45,28 @@ func AESDecrypt(encryptedString string) (decryptedString string, err error) {
 		return "", errors.New("error in decoding encrypted string")
 	}
 
-	//Create a new Cipher Block from the key
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
+	//Extract the nonce from the encrypted data
+	nonceSize := aesGCM.NonceSize()
+	if len(enc) < nonceSize {
+		return "", ErrDecrypt
 	}
+	nonce, ciphertext := enc[:nonceSize], enc[nonceSize:]
 
-	//Create a new GCM
-	aesGCM, err := cipher.NewGCM(block)
+	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return "", err
+		return "", ErrDecrypt
 	}
 
-	//Get the nonce size
-	nonceSize := aesGCM.NonceSize()
-
-	//Extract the nonce from the encrypted data
-	nonce, ciphertext := enc[:nonceSize], enc[nonceSize:]
+	return string(plaintext), nil
+}
 
-	//Decrypt the data
-	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, nil)
+func newGCM(key []byte) (cipher.AEAD, error) {
+	//Create a new Cipher Block from the key
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", nil
+		return nil, err
 	}
 
-	return string(plaintext), nil
-}
\ No newline at end of file
+	//Create a new GCM - https://en.wikipedia.org/wiki/Galois/Counter_Mode
+	return cipher.NewGCM(block)
+}
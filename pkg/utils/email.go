@@ -1,11 +1,34 @@
 package utils
 
 import (
+	"bytes"
+	"embed"
+	"html/template"
+
 	"blog/config"
 
 	"gopkg.in/gomail.v2"
 )
 
+//go:embed email-template/*.html
+var emailTemplates embed.FS
+
+// RenderMailTemplate executes one of the templates in email-template, named
+// without its extension, and returns the HTML body
+func RenderMailTemplate(name string, data any) (string, error) {
+	tmpl, err := template.ParseFS(emailTemplates, "email-template/"+name+".html")
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
 func SendMail(toEmail string, subject string, body string) error {
 	emailConfig, err := config.NewEmailConfig()
 	if err != nil {
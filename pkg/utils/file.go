@@ -1,50 +1,71 @@
 package utils
 
 import (
-	"fmt"
+	"context"
+	"crypto/sha256"
 	"io"
 	"mime/multipart"
-	"os"
+	"net/http"
 	"strings"
-)
-
-const PATH = "assets"
 
-func UploadFile(file *multipart.FileHeader, path string) error {
-	parts := strings.Split(path, "/")
-	fileID := parts[1]
-	dirPath := fmt.Sprintf("%s/%s", PATH, parts[0])
-
-	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(dirPath, 0777); err != nil {
-			return err
-		}
-	}
+	"blog/pkg/storage"
+)
 
-	filePath := fmt.Sprintf("%s/%s", dirPath, fileID)
+// uploadExtensions maps sniffed content types to the extension files of that
+// type are stored with, anything else is kept as opaque binary
+var uploadExtensions = map[string]string{
+	"image/jpeg":                "jpg",
+	"image/png":                 "png",
+	"image/gif":                 "gif",
+	"image/webp":                "webp",
+	"application/pdf":           "pdf",
+	"application/zip":           "zip",
+	"text/plain; charset=utf-8": "txt",
+}
 
+// UploadFile stores an uploaded file below prefix under a key derived from
+// its content and returns the key. The type is sniffed from the content, the
+// name and type the client sent are not trusted.
+func UploadFile(ctx context.Context, store storage.Storage, file *multipart.FileHeader, prefix string) (string, error) {
 	uploadedFile, err := file.Open()
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer uploadedFile.Close()
 
-	// Using os.Create to open the file with appropriate permissions
-	targetFile, err := os.Create(filePath)
-	if err != nil {
-		return err
+	head := make([]byte, 512)
+	n, err := io.ReadFull(uploadedFile, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
 	}
-	defer targetFile.Close()
 
-	// Copy file contents from uploadedFile to targetFile
-	_, err = io.Copy(targetFile, uploadedFile)
-	if err != nil {
-		return err
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := uploadExtensions[contentType]
+	if !ok {
+		contentType, ext = "application/octet-stream", "bin"
 	}
 
-	return nil
+	// Hashed in one pass and stored in another, so the storage can stream the
+	// file instead of holding it in memory
+	if _, err := uploadedFile.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, uploadedFile); err != nil {
+		return "", err
+	}
+	if _, err := uploadedFile.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	key := storage.ContentKey(prefix, hash.Sum(nil), ext)
+	if err := store.Put(ctx, key, uploadedFile, contentType); err != nil {
+		return "", err
+	}
+
+	return key, nil
 }
 
 func GetExtensions(filename string) string {
 	return strings.Split(filename, ".")[len(strings.Split(filename, "."))-1]
-}
\ No newline at end of file
+}
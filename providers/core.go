@@ -2,20 +2,35 @@ package providers
 
 import (
 	"blog/config"
+	apiKeyController "blog/modules/apikey/controller"
+	apiKeyRepo "blog/modules/apikey/repository"
+	apiKeyService "blog/modules/apikey/service"
 	authController "blog/modules/auth/controller"
 	authRepo "blog/modules/auth/repository"
 	authService "blog/modules/auth/service"
+	oauthServerController "blog/modules/oauth/controller"
+	oauthServerRepo "blog/modules/oauth/repository"
+	oauthServerService "blog/modules/oauth/service"
+	rbacController "blog/modules/rbac/controller"
+	rbacRepo "blog/modules/rbac/repository"
+	rbacService "blog/modules/rbac/service"
 	userController "blog/modules/user/controller"
+	userPolicy "blog/modules/user/policy"
 	userRepo "blog/modules/user/repository"
 	userService "blog/modules/user/service"
+	"blog/pkg/cache"
 	"blog/pkg/constants"
+	"blog/pkg/export"
+	"blog/pkg/helpers"
+	"blog/pkg/storage"
 
-	"gorm.io/gorm"
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/samber/do"
+	"gorm.io/gorm"
 )
 
 func InDatabase(injector *do.Injector) {
-	do.ProvideNamed(injector, constants.DB, func (i *do.Injector) (*gorm.DB, error) {
+	do.ProvideNamed(injector, constants.DB, func(i *do.Injector) (*gorm.DB, error) {
 		return config.SetUpDatabaseConnection(), nil
 	})
 }
@@ -24,21 +39,259 @@ func RegisterDependencies(injector *do.Injector) {
 	InDatabase(injector)
 
 	do.ProvideNamed(injector, constants.JWTService, func(i *do.Injector) (authService.JWTService, error) {
-		return authService.NewJWTService(), nil
+		cfg, err := config.NewJWTConfig()
+		if err != nil {
+			return nil, err
+		}
+		return authService.NewJWTService(cfg)
+	})
+
+	do.ProvideNamed(injector, constants.CacheStore, func(i *do.Injector) (cache.Store, error) {
+		return cache.NewMemoryStore(), nil
+	})
+
+	do.ProvideNamed(injector, constants.TokenRevocationService, func(i *do.Injector) (authService.TokenRevocationService, error) {
+		store := do.MustInvokeNamed[cache.Store](i, constants.CacheStore)
+		jwtService := do.MustInvokeNamed[authService.JWTService](i, constants.JWTService)
+		return authService.NewTokenRevocationService(store, jwtService), nil
+	})
+
+	do.ProvideNamed(injector, constants.LoginThrottleService, func(i *do.Injector) (authService.LoginThrottleService, error) {
+		cfg, err := config.NewLoginThrottleConfig()
+		if err != nil {
+			return nil, err
+		}
+		store := do.MustInvokeNamed[cache.Store](i, constants.CacheStore)
+		return authService.NewLoginThrottleService(cfg, store), nil
+	})
+
+	do.ProvideNamed(injector, constants.OAuthProviders, func(i *do.Injector) ([]authService.OAuthProvider, error) {
+		cfg, err := config.NewOAuthConfig()
+		if err != nil {
+			return nil, err
+		}
+		return authService.NewOAuthProviders(cfg)
+	})
+
+	do.ProvideNamed(injector, constants.WebAuthn, func(i *do.Injector) (*webauthn.WebAuthn, error) {
+		return authService.NewWebAuthn(config.NewWebAuthnConfig())
+	})
+
+	do.ProvideNamed(injector, constants.TwoFactorConfig, func(i *do.Injector) (*config.TwoFactorConfig, error) {
+		return config.NewTwoFactorConfig()
+	})
+
+	do.ProvideNamed(injector, constants.MagicLinkConfig, func(i *do.Injector) (*config.MagicLinkConfig, error) {
+		return config.NewMagicLinkConfig()
+	})
+
+	do.ProvideNamed(injector, constants.EmailChangeConfig, func(i *do.Injector) (*config.EmailChangeConfig, error) {
+		return config.NewEmailChangeConfig()
+	})
+
+	do.ProvideNamed(injector, constants.UserDeletionConfig, func(i *do.Injector) (*config.UserDeletionConfig, error) {
+		return config.NewUserDeletionConfig()
+	})
+
+	do.ProvideNamed(injector, constants.DataExportConfig, func(i *do.Injector) (*config.DataExportConfig, error) {
+		return config.NewDataExportConfig()
+	})
+
+	do.ProvideNamed(injector, constants.AvatarConfig, func(i *do.Injector) (*config.AvatarConfig, error) {
+		return config.NewAvatarConfig()
+	})
+
+	do.ProvideNamed(injector, constants.Storage, func(i *do.Injector) (storage.Storage, error) {
+		cfg, err := config.NewStorageConfig()
+		if err != nil {
+			return nil, err
+		}
+		return storage.New(cfg)
+	})
+
+	do.ProvideNamed(injector, constants.PasswordPolicyService, func(i *do.Injector) (authService.PasswordPolicyService, error) {
+		cfg, err := config.NewPasswordPolicyConfig()
+		if err != nil {
+			return nil, err
+		}
+		hasher, err := authService.NewPasswordHasher(cfg)
+		if err != nil {
+			return nil, err
+		}
+		helpers.SetPasswordHasher(hasher)
+		breachedChecker, err := authService.NewBreachedPasswordChecker(cfg.BreachedListPath)
+		if err != nil {
+			return nil, err
+		}
+		db := do.MustInvokeNamed[*gorm.DB](i, constants.DB)
+		return authService.NewPasswordPolicyService(cfg, authRepo.NewPasswordHistoryRepository(db), breachedChecker), nil
 	})
 
 	db := do.MustInvokeNamed[*gorm.DB](injector, constants.DB)
 	jwtService := do.MustInvokeNamed[authService.JWTService](injector, constants.JWTService)
+	revocationService := do.MustInvokeNamed[authService.TokenRevocationService](injector, constants.TokenRevocationService)
+	store := do.MustInvokeNamed[cache.Store](injector, constants.CacheStore)
+	loginThrottleService := do.MustInvokeNamed[authService.LoginThrottleService](injector, constants.LoginThrottleService)
+	oauthProviders := do.MustInvokeNamed[[]authService.OAuthProvider](injector, constants.OAuthProviders)
+	webAuthn := do.MustInvokeNamed[*webauthn.WebAuthn](injector, constants.WebAuthn)
+	twoFactorConfig := do.MustInvokeNamed[*config.TwoFactorConfig](injector, constants.TwoFactorConfig)
+	magicLinkConfig := do.MustInvokeNamed[*config.MagicLinkConfig](injector, constants.MagicLinkConfig)
+	emailChangeConfig := do.MustInvokeNamed[*config.EmailChangeConfig](injector, constants.EmailChangeConfig)
+	userDeletionConfig := do.MustInvokeNamed[*config.UserDeletionConfig](injector, constants.UserDeletionConfig)
+	dataExportConfig := do.MustInvokeNamed[*config.DataExportConfig](injector, constants.DataExportConfig)
+	avatarConfig := do.MustInvokeNamed[*config.AvatarConfig](injector, constants.AvatarConfig)
+	fileStorage := do.MustInvokeNamed[storage.Storage](injector, constants.Storage)
+	passwordPolicyService := do.MustInvokeNamed[authService.PasswordPolicyService](injector, constants.PasswordPolicyService)
 
 	userRepository := userRepo.NewUserRepository(db)
 	refreshTokenRepository := authRepo.NewRefreshTokenRepository(db)
+	oneTimeTokenRepository := authRepo.NewOneTimeTokenRepository(db)
+	twoFactorRepository := authRepo.NewTwoFactorRepository(db)
+	recoveryCodeRepository := authRepo.NewRecoveryCodeRepository(db)
+	linkedIdentityRepository := authRepo.NewLinkedIdentityRepository(db)
+	webAuthnCredentialRepository := authRepo.NewWebAuthnCredentialRepository(db)
+	roleRepository := rbacRepo.NewRoleRepository(db)
+	permissionRepository := rbacRepo.NewPermissionRepository(db)
+	oauthClientRepository := oauthServerRepo.NewClientRepository(db)
+	authorizationCodeRepository := oauthServerRepo.NewAuthorizationCodeRepository(db)
+	consentRepository := oauthServerRepo.NewConsentRepository(db)
+	apiKeyRepository := apiKeyRepo.NewAPIKeyRepository(db)
+	pendingEmailChangeRepository := userRepo.NewPendingEmailChangeRepository(db)
+
+	export.Provide(injector, userService.NewProfileExporter(userRepository, db))
+	export.Provide(injector, authService.NewSessionExporter(refreshTokenRepository, db))
+	export.Provide(injector, authService.NewLinkedIdentityExporter(linkedIdentityRepository, db))
+	export.Provide(injector, authService.NewPasskeyExporter(webAuthnCredentialRepository, db))
+	export.Provide(injector, apiKeyService.NewAPIKeyExporter(apiKeyRepository, db))
+	export.Provide(injector, oauthServerService.NewConsentExporter(consentRepository, db))
 
-	userService := userService.NewUserService(userRepository, db)
-	authService := authService.NewAuthService(userRepository, refreshTokenRepository, jwtService, db)
+	sessionService := authService.NewSessionService(refreshTokenRepository, jwtService, revocationService, db)
+	twoFactorService := authService.NewTwoFactorService(
+		twoFactorRepository,
+		recoveryCodeRepository,
+		userRepository,
+		jwtService,
+		revocationService,
+		store,
+		twoFactorConfig,
+		db,
+	)
+	oauthService := authService.NewOAuthService(
+		oauthProviders,
+		userRepository,
+		linkedIdentityRepository,
+		refreshTokenRepository,
+		jwtService,
+		twoFactorService,
+		store,
+		db,
+	)
+	passkeyService := authService.NewPasskeyService(
+		webAuthn,
+		webAuthnCredentialRepository,
+		userRepository,
+		refreshTokenRepository,
+		jwtService,
+		twoFactorService,
+		store,
+		db,
+	)
+	authService := authService.NewAuthService(
+		userRepository,
+		refreshTokenRepository,
+		oneTimeTokenRepository,
+		jwtService,
+		revocationService,
+		twoFactorService,
+		loginThrottleService,
+		passwordPolicyService,
+		magicLinkConfig,
+		store,
+		db,
+	)
+	rbacService := rbacService.NewRBACService(
+		roleRepository,
+		permissionRepository,
+		userRepository,
+		revocationService,
+		store,
+		db,
+	)
+
+	clientService := oauthServerService.NewClientService(oauthClientRepository, db)
+	authorizationService := oauthServerService.NewAuthorizationService(
+		oauthClientRepository,
+		authorizationCodeRepository,
+		consentRepository,
+		db,
+	)
+	tokenService := oauthServerService.NewTokenService(
+		clientService,
+		oauthClientRepository,
+		authorizationCodeRepository,
+		consentRepository,
+		jwtService,
+		revocationService,
+		db,
+	)
+
+	apiKeyService := apiKeyService.NewAPIKeyService(apiKeyRepository, userRepository, rbacService, db)
+
+	emailChangeService := userService.NewEmailChangeService(
+		userRepository,
+		pendingEmailChangeRepository,
+		refreshTokenRepository,
+		revocationService,
+		loginThrottleService,
+		emailChangeConfig,
+		db,
+	)
+	avatarService := userService.NewAvatarService(userRepository, fileStorage, avatarConfig, db)
+	dataExportService := userService.NewDataExportService(
+		injector,
+		userRepository,
+		fileStorage,
+		dataExportConfig,
+		store,
+		db,
+	)
+	userService := userService.NewUserService(
+		userRepository,
+		refreshTokenRepository,
+		revocationService,
+		rbacService,
+		emailChangeService,
+		avatarService,
+		dataExportService,
+		userDeletionConfig,
+		db,
+	)
+	userPolicy := userPolicy.NewUserPolicy(rbacService)
+
+	do.ProvideNamedValue(injector, constants.RBACService, rbacService)
+	do.ProvideNamedValue(injector, constants.APIKeyService, apiKeyService)
 
 	do.Provide(
 		injector, func(i *do.Injector) (userController.UserController, error) {
-			return userController.NewUserController(i, userService), nil
+			return userController.NewUserController(i, userService, authService, userPolicy, avatarService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (userController.EmailChangeController, error) {
+			return userController.NewEmailChangeController(emailChangeService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (userController.DataExportController, error) {
+			return userController.NewDataExportController(dataExportService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (userController.AvatarController, error) {
+			return userController.NewAvatarController(avatarService), nil
 		},
 	)
 
@@ -47,4 +300,58 @@ func RegisterDependencies(injector *do.Injector) {
 			return authController.NewAuthController(i, authService), nil
 		},
 	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (authController.SessionController, error) {
+			return authController.NewSessionController(sessionService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (authController.TwoFactorController, error) {
+			return authController.NewTwoFactorController(twoFactorService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (authController.OAuthController, error) {
+			return authController.NewOAuthController(oauthService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (authController.PasskeyController, error) {
+			return authController.NewPasskeyController(passkeyService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (rbacController.RBACController, error) {
+			return rbacController.NewRBACController(rbacService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (oauthServerController.ClientController, error) {
+			return oauthServerController.NewClientController(clientService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (oauthServerController.AuthorizationController, error) {
+			return oauthServerController.NewAuthorizationController(authorizationService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (oauthServerController.TokenController, error) {
+			return oauthServerController.NewTokenController(tokenService), nil
+		},
+	)
+
+	do.Provide(
+		injector, func(i *do.Injector) (apiKeyController.APIKeyController, error) {
+			return apiKeyController.NewAPIKeyController(apiKeyService), nil
+		},
+	)
 }
\ No newline at end of file